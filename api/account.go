@@ -3,9 +3,9 @@ package api
 import (
 	"net/http"
 
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
 	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 )
 
 type createAccountRequest struct {
@@ -13,10 +13,24 @@ type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,oneof=USD EUR RUB CAD"`
 }
 
+// createAccount opens an empty account.
+//
+// @Summary     Create account
+// @Description Open an empty account in the given currency
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       request  body      createAccountRequest  true  "Account to create"
+// @Success     200      {object}  db.Account
+// @Failure     400      {object}  apperr.Problem
+// @Failure     409      {object}  apperr.Problem  "owner already has an account in this currency"
+// @Failure     422      {object}  apperr.Problem  "owner does not exist"
+// @Failure     500      {object}  apperr.Problem
+// @Router      /accounts [post]
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apperr.Invalid(err))
 		return
 	}
 
@@ -26,13 +40,13 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		Balance:  0,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	writeAccount(ctx, http.StatusOK, account)
 }
 
 type GetAccountRequest struct {
@@ -46,30 +60,35 @@ type GetAccountRequest struct {
 // @Tags        accounts
 // @Accept      json
 // @Produce     json
-// @Param       id   path      int64  true  "Account ID"
+// @Param       id                  path      int64   true   "Account ID"
+// @Param       X-Read-Your-Writes  header    bool    false  "Read from the primary to see the caller's latest writes"
+// @Param       If-None-Match       header    string  false  "ETag of a cached copy"
 // @Success     200  {object}  db.Account
-// @Failure     400  {object}  errorResponse
-// @Failure     404  {object}  errorResponse
-// @Failure     500  {object}  errorResponse
+// @Header      200  {string}  ETag  "Account version, for If-Match on updates"
+// @Success     304  "Not modified"
+// @Failure     400  {object}  apperr.Problem
+// @Failure     404  {object}  apperr.Problem
+// @Failure     500  {object}  apperr.Problem
 // @Router      /accounts/{id} [get]
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req GetAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apperr.Invalid(err))
 		return
 	}
 
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeError(ctx, apperr.NotFoundAs(err, "account %d", req.ID))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	if etag := accountETag(account); notModified(ctx, etag) {
+		ctx.Header("ETag", etag)
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	writeAccount(ctx, http.StatusOK, account)
 }
 
 type ListAccountsRequest struct {
@@ -77,10 +96,22 @@ type ListAccountsRequest struct {
 	Page  int32 `form:"page" binding:"required,min=1"`
 }
 
+// listAccounts lists accounts page by page.
+//
+// @Summary     List accounts
+// @Tags        accounts
+// @Produce     json
+// @Param       limit               query     int   true   "Page size"  minimum(5)  maximum(10)
+// @Param       page                query     int   true   "Page number"  minimum(1)
+// @Param       X-Read-Your-Writes  header    bool  false  "Read from the primary to see the caller's latest writes"
+// @Success     200    {array}   db.Account
+// @Failure     400    {object}  apperr.Problem
+// @Failure     500    {object}  apperr.Problem
+// @Router      /accounts [get]
 func (server *Server) listAccounts(ctx *gin.Context) {
 	var req ListAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apperr.Invalid(err))
 		return
 	}
 
@@ -89,7 +120,7 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		Offset: (req.Page - 1) * req.Limit,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeError(ctx, err)
 		return
 	}
 
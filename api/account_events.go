@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	replayPageSize    = 1000
+	heartbeatInterval = 15 * time.Second
+)
+
+type accountBalance struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+}
+
+// streamAccountEvents streams new entries ("entry" events, id = entry id) and
+// balance changes ("balance" events) of an account as server-sent events.
+// Every entry after Last-Event-ID is replayed from the database on reconnect,
+// page by page, before live events are sent.
+//
+// @Summary     Stream account events
+// @Description Server-sent events: "entry" carries a db.Entry, "balance" the new balance
+// @Tags        accounts
+// @Produce     text/event-stream
+// @Param       id             path      int64   true   "Account ID"
+// @Param       Last-Event-ID  header    string  false  "Replay entries after this ID"
+// @Success     200            {string}  string  "event stream"
+// @Failure     400            {object}  apperr.Problem
+// @Failure     404            {object}  apperr.Problem
+// @Failure     500            {object}  apperr.Problem
+// @Router      /accounts/{id}/events [get]
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var req GetAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	var lastEventID int64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			writeError(ctx, apperr.InvalidField("Last-Event-ID", "min", "must be a non-negative entry ID"))
+			return
+		}
+		lastEventID = id
+	}
+
+	// subscribe before reading the account so no event falls in between
+	sub := server.hub.subscribe(req.ID)
+	defer server.hub.unsubscribe(req.ID, sub)
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		writeError(ctx, apperr.NotFoundAs(err, "account %d", req.ID))
+		return
+	}
+
+	var missed []db.Entry
+	if lastEventID > 0 {
+		missed, err = server.listEntriesAfter(ctx, req.ID, lastEventID)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	// the stream outlives the server's write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(ctx).Debug("cannot clear write deadline of event stream", "error", err)
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+
+	for pages := 1; len(missed) > 0; pages++ {
+		for _, entry := range missed {
+			writeEntryEvent(ctx, entry)
+			lastEventID = entry.ID
+		}
+		ctx.Writer.Flush()
+		if len(missed) < replayPageSize {
+			if pages > 1 {
+				// the balance read before the replay predates most of it
+				account, err = server.store.GetAccount(ctx, req.ID)
+			}
+			break
+		}
+
+		missed, err = server.listEntriesAfter(ctx, req.ID, lastEventID)
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		// the client resumes after the last entry it received
+		logging.FromContext(ctx).Error("failed to replay account entries", "error", err)
+		return
+	}
+	ctx.Render(-1, sse.Event{Event: "balance", Data: accountBalance{AccountID: account.ID, Balance: account.Balance}})
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-server.stopping.Done():
+			return
+		case <-sub.dropped:
+			return
+		case <-heartbeat.C:
+			ctx.Writer.WriteString(": ping\n\n")
+		case event := <-sub.events:
+			if event.Entry.ID <= lastEventID {
+				continue
+			}
+			writeEntryEvent(ctx, event.Entry)
+			lastEventID = event.Entry.ID
+			ctx.Render(-1, sse.Event{Event: "balance", Data: accountBalance{AccountID: event.AccountID, Balance: event.Balance}})
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func (server *Server) listEntriesAfter(ctx *gin.Context, accountID, afterID int64) ([]db.Entry, error) {
+	return server.store.ListEntriesByAccountAfter(ctx, db.ListEntriesByAccountAfterParams{
+		AccountID: accountID,
+		AfterID:   afterID,
+		Limit:     replayPageSize,
+	})
+}
+
+func writeEntryEvent(ctx *gin.Context, entry db.Entry) {
+	ctx.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(entry.ID, 10),
+		Event: "entry",
+		Data:  entry,
+	})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	account := randAccount(util.RandomCurrency())
+	missed := []db.Entry{
+		{ID: 11, AccountID: account.ID, Amount: 5},
+		{ID: 12, AccountID: account.ID, Amount: -3},
+	}
+	fullPage := make([]db.Entry, replayPageSize)
+	for i := range fullPage {
+		fullPage[i] = db.Entry{ID: int64(11 + i), AccountID: account.ID, Amount: 1}
+	}
+
+	testCases := []struct {
+		name          string
+		lastEventID   string
+		buildStubs    func(store *mockdb.MockStore)
+		live          []db.AccountEvent
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Live",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntriesByAccountAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			live: []db.AccountEvent{
+				{AccountID: account.ID, Balance: account.Balance + 7, Entry: db.Entry{ID: 20, AccountID: account.ID, Amount: 7}},
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				body := recorder.Body.String()
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/event-stream")
+				require.Contains(t, body, fmt.Sprintf(`"balance":%d`, account.Balance))
+				require.Contains(t, body, "id:20\nevent:entry\n")
+				require.Contains(t, body, fmt.Sprintf(`"balance":%d`, account.Balance+7))
+			},
+		},
+		{
+			name:        "Replay",
+			lastEventID: "10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntriesByAccountAfter(gomock.Any(), db.ListEntriesByAccountAfterParams{
+					AccountID: account.ID,
+					AfterID:   10,
+					Limit:     replayPageSize,
+				}).Times(1).Return(missed, nil)
+			},
+			live: []db.AccountEvent{
+				// already replayed, must not be sent twice
+				{AccountID: account.ID, Balance: account.Balance, Entry: missed[1]},
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				body := recorder.Body.String()
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, body, "id:11\nevent:entry\n")
+				require.Equal(t, 1, strings.Count(body, "id:12\n"))
+				require.Less(t, strings.Index(body, "id:11\n"), strings.Index(body, "id:12\n"))
+			},
+		},
+		{
+			name:        "ReplayPages",
+			lastEventID: "10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				gomock.InOrder(
+					store.EXPECT().ListEntriesByAccountAfter(gomock.Any(), db.ListEntriesByAccountAfterParams{
+						AccountID: account.ID,
+						AfterID:   10,
+						Limit:     replayPageSize,
+					}).Times(1).Return(fullPage, nil),
+					store.EXPECT().ListEntriesByAccountAfter(gomock.Any(), db.ListEntriesByAccountAfterParams{
+						AccountID: account.ID,
+						AfterID:   10 + replayPageSize,
+						Limit:     replayPageSize,
+					}).Times(1).Return([]db.Entry{{ID: 11 + replayPageSize, AccountID: account.ID}}, nil),
+				)
+			},
+			live: []db.AccountEvent{},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				body := recorder.Body.String()
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, replayPageSize+1, strings.Count(body, "event:entry\n"))
+				require.Contains(t, body, fmt.Sprintf("id:%d\n", 11+replayPageSize))
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, pgx.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidLastEventID",
+			lastEventID: "abc",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			url := fmt.Sprintf("/accounts/%d/events", account.ID)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				server.router.ServeHTTP(recorder, req)
+				close(done)
+			}()
+
+			if tc.live != nil {
+				require.Eventually(t, func() bool {
+					server.hub.mu.Lock()
+					defer server.hub.mu.Unlock()
+					return len(server.hub.subscribers[account.ID]) == 1
+				}, time.Second, time.Millisecond)
+				// give the handler time to finish the initial snapshot
+				time.Sleep(20 * time.Millisecond)
+
+				for _, event := range tc.live {
+					server.hub.publish(event)
+				}
+				time.Sleep(20 * time.Millisecond)
+				cancel()
+			}
+
+			<-done
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAccountEventHubDropsSlowSubscriber(t *testing.T) {
+	hub := newAccountEventHub()
+	slow := hub.subscribe(1)
+	other := hub.subscribe(2)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.publish(db.AccountEvent{AccountID: 1, Entry: db.Entry{ID: int64(i + 1)}})
+	}
+
+	select {
+	case <-slow.dropped:
+	default:
+		t.Fatal("slow subscriber was not dropped")
+	}
+	require.Len(t, slow.events, subscriberBuffer)
+	require.NotContains(t, hub.subscribers, int64(1))
+
+	hub.publish(db.AccountEvent{AccountID: 2})
+	require.Len(t, other.events, 1)
+
+	hub.dropAll()
+	select {
+	case <-other.dropped:
+	default:
+		t.Fatal("subscriber was not dropped")
+	}
+	require.Empty(t, hub.subscribers)
+}
@@ -23,8 +23,11 @@ func randAccount(currency string) db.Account {
 		Owner:    util.RandomOwner(),
 		Balance:  rand.Int63n(100) + 10,
 		Currency: currency,
+		Status:   db.AccountStatusActive,
+		Version:  int64(util.RandomInt(1, 10)),
 	}
 }
+
 func TestGetAccountAPI(t *testing.T) {
 	currency := util.RandomCurrency()
 	account := randAccount(currency)
@@ -195,3 +198,43 @@ func TestListAccountsAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAccountETag(t *testing.T) {
+	account := randAccount(util.RandomCurrency())
+	etag := fmt.Sprintf(`"%d"`, account.Version)
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		code        int
+	}{
+		{name: "NoValidator", code: http.StatusOK},
+		{name: "Match", ifNoneMatch: etag, code: http.StatusNotModified},
+		{name: "WeakMatch", ifNoneMatch: `"0", W/` + etag, code: http.StatusNotModified},
+		{name: "Stale", ifNoneMatch: fmt.Sprintf(`"%d"`, account.Version-1), code: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			require.Equal(t, tc.code, recorder.Code)
+			require.Equal(t, etag, recorder.Header().Get("ETag"))
+			if tc.code == http.StatusNotModified {
+				require.Empty(t, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type verifyChainRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// verifyAccountChain recomputes the entry hash chain of an account.
+//
+// @Summary     Verify account hash chain
+// @Tags        admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id   path      int64  true  "Account ID"
+// @Success     200  {object}  db.ChainVerification
+// @Failure     400  {object}  apperr.Problem
+// @Failure     401  {object}  apperr.Problem
+// @Failure     403  {object}  apperr.Problem
+// @Failure     404  {object}  apperr.Problem
+// @Failure     500  {object}  apperr.Problem
+// @Router      /admin/accounts/{id}/verify_chain [get]
+func (server *Server) verifyAccountChain(ctx *gin.Context) {
+	var req verifyChainRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	if _, err := server.store.GetAccount(ctx, req.ID); err != nil {
+		writeError(ctx, apperr.NotFoundAs(err, "account %d", req.ID))
+		return
+	}
+
+	result, err := server.store.VerifyAccountChain(ctx, req.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type listAuditLogRequest struct {
+	Target string `form:"target" binding:"required"`
+	Limit  int32  `form:"limit" binding:"required,min=5,max=100"`
+	Page   int32  `form:"page" binding:"required,min=1"`
+}
+
+// listAuditLog returns audit records touching a resource given as kind:id,
+// e.g. transfer:42, newest first.
+//
+// @Summary     List audit log
+// @Tags        admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       target  query     string  true  "Resource as kind:id, e.g. account:42"
+// @Param       limit   query     int     true  "Page size"  minimum(5)  maximum(100)
+// @Param       page    query     int     true  "Page number"  minimum(1)
+// @Success     200     {array}   db.AuditLog
+// @Failure     400     {object}  apperr.Problem
+// @Failure     401     {object}  apperr.Problem
+// @Failure     403     {object}  apperr.Problem
+// @Failure     500     {object}  apperr.Problem
+// @Router      /admin/audit_log [get]
+func (server *Server) listAuditLog(ctx *gin.Context) {
+	var req listAuditLogRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	records, err := server.store.ListAuditLogByTarget(ctx, db.ListAuditLogByTargetParams{
+		Target: req.Target,
+		Limit:  req.Limit,
+		Offset: (req.Page - 1) * req.Limit,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, records)
+}
+
+type listFailedJobsRequest struct {
+	Limit int32 `form:"limit" binding:"required,min=5,max=100"`
+	Page  int32 `form:"page" binding:"required,min=1"`
+}
+
+// listFailedJobs returns background jobs that ran out of attempts or failed
+// permanently, newest first.
+//
+// @Summary     List failed jobs
+// @Tags        admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       limit  query     int  true  "Page size"  minimum(5)  maximum(100)
+// @Param       page   query     int  true  "Page number"  minimum(1)
+// @Success     200    {array}   db.Job
+// @Failure     400    {object}  apperr.Problem
+// @Failure     401    {object}  apperr.Problem
+// @Failure     403    {object}  apperr.Problem
+// @Failure     500    {object}  apperr.Problem
+// @Router      /admin/jobs/failed [get]
+func (server *Server) listFailedJobs(ctx *gin.Context) {
+	var req listFailedJobsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	jobs, err := server.store.ListJobsByStatus(ctx, db.ListJobsByStatusParams{
+		Status: db.JobStatusFailed,
+		Limit:  req.Limit,
+		Offset: (req.Page - 1) * req.Limit,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+type accountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+}
+
+// updateAccountStatus activates, freezes or closes an account. If-Match must
+// carry the ETag from getAccount, so that concurrent edits fail with 412
+// instead of silently overwriting each other.
+//
+// @Summary     Change account status
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id        path      int64                       true  "Account ID"
+// @Param       If-Match  header    string                      true  "ETag of the account, or * to skip the check"
+// @Param       request   body      updateAccountStatusRequest  true  "New status"
+// @Success     200       {object}  db.Account
+// @Header      200       {string}  ETag  "New account version"
+// @Failure     400       {object}  apperr.Problem
+// @Failure     401       {object}  apperr.Problem
+// @Failure     403       {object}  apperr.Problem
+// @Failure     404       {object}  apperr.Problem
+// @Failure     412       {object}  apperr.Problem  "account changed since the ETag was read"
+// @Failure     428       {object}  apperr.Problem  "If-Match header missing"
+// @Failure     500       {object}  apperr.Problem
+// @Router      /admin/accounts/{id}/status [put]
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri accountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	account, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		ID:        uri.ID,
+		Status:    req.Status,
+		IfVersion: version,
+	})
+	if err != nil {
+		writeError(ctx, apperr.NotFoundAs(err, "account %d", uri.ID))
+		return
+	}
+
+	writeAccount(ctx, http.StatusOK, account)
+}
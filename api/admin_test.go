@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyAccountChainAPI(t *testing.T) {
+	admin, password := randomUserWithPassword(t)
+	account := randAccount(util.RandomCurrency())
+	tampered := db.ChainVerification{
+		AccountID:       account.ID,
+		EntriesChecked:  3,
+		TamperedEntryID: 42,
+		Reason:          "hash does not match entry contents",
+	}
+
+	testCases := []struct {
+		name          string
+		accountId     int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Tampered",
+			accountId: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().VerifyAccountChain(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(tampered, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var got db.ChainVerification
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, tampered, got)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountId: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, pgx.ErrNoRows)
+				store.EXPECT().VerifyAccountChain(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountId: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().VerifyAccountChain(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.ChainVerification{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidId",
+			accountId: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			server.UseAdmins([]string{admin.Username})
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/accounts/%d/verify_chain", tc.accountId)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(req, store, admin, password)
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateAccountStatusAPI(t *testing.T) {
+	admin, password := randomUserWithPassword(t)
+	account := randAccount(util.RandomCurrency())
+	frozen := account
+	frozen.Status = db.AccountStatusFrozen
+	frozen.Version++
+
+	testCases := []struct {
+		name          string
+		ifMatch       string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			ifMatch: fmt.Sprintf(`"%d"`, account.Version),
+			body:    gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{
+					ID:        account.ID,
+					Status:    db.AccountStatusFrozen,
+					IfVersion: account.Version,
+				})).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, frozen.Version), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:    "Wildcard",
+			ifMatch: "*",
+			body:    gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{
+					ID:     account.ID,
+					Status: db.AccountStatusFrozen,
+				})).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "VersionChanged",
+			ifMatch: fmt.Sprintf(`"%d"`, account.Version),
+			body:    gin.H{"status": db.AccountStatusClosed},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Account{}, apperr.New(apperr.CodePreconditionFailed, "account %d has changed", account.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "MissingIfMatch",
+			body: gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+			},
+		},
+		{
+			name:    "WeakETag",
+			ifMatch: fmt.Sprintf(`W/"%d"`, account.Version),
+			body:    gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:    "MalformedETag",
+			ifMatch: "abc",
+			body:    gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidStatus",
+			ifMatch: "*",
+			body:    gin.H{"status": "deleted"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "NotFound",
+			ifMatch: "*",
+			body:    gin.H{"status": db.AccountStatusFrozen},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, pgx.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			server.UseAdmins([]string{admin.Username})
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/accounts/%d/status", account.ID)
+			req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			addAuthorization(req, store, admin, password)
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListFailedJobsAPI(t *testing.T) {
+	admin, password := randomUserWithPassword(t)
+	failed := []db.Job{{
+		ID:          7,
+		Kind:        db.JobVerifyAccountChain,
+		Payload:     json.RawMessage(`{"account_id":3}`),
+		Status:      db.JobStatusFailed,
+		Attempts:    1,
+		MaxAttempts: db.DefaultJobMaxAttempts,
+		LastError:   pgtype.Text{String: "account 3 hash chain is broken at entry 9", Valid: true},
+	}}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "limit=5&page=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListJobsByStatus(gomock.Any(), gomock.Eq(db.ListJobsByStatusParams{Status: db.JobStatusFailed, Limit: 5, Offset: 5})).
+					Times(1).
+					Return(failed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 1)
+				require.Equal(t, map[string]any{"account_id": float64(3)}, got[0]["payload"])
+				require.Equal(t, failed[0].LastError.String, got[0]["last_error"])
+			},
+		},
+		{
+			name:  "InvalidLimit",
+			query: "limit=1000&page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListJobsByStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "limit=5&page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListJobsByStatus(gomock.Any(), gomock.Any()).Times(1).Return(nil, pgx.ErrTxClosed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			server.UseAdmins([]string{admin.Username})
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/jobs/failed?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(req, store, admin, password)
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAuditLogAPI(t *testing.T) {
+	admin, password := randomUserWithPassword(t)
+	records := []db.AuditLog{{
+		ID:          3,
+		Actor:       "anonymous",
+		Method:      http.MethodPost,
+		Route:       "/accounts",
+		TargetIds:   []string{"account:7"},
+		Outcome:     db.AuditOutcomeCommitted,
+		RequestBody: json.RawMessage(`{"currency":"USD","owner":"alice"}`),
+	}}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAuditLogByTarget(gomock.Any(), gomock.Eq(db.ListAuditLogByTargetParams{Target: "account:7", Limit: 5, Offset: 0})).
+		Times(1).
+		Return(records, nil)
+
+	server := NewServer(store)
+	server.UseAdmins([]string{admin.Username})
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/admin/audit_log?target=account:7&limit=5&page=1", nil)
+	require.NoError(t, err)
+	addAuthorization(req, store, admin, password)
+
+	server.router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	require.Equal(t, map[string]any{"currency": "USD", "owner": "alice"}, got[0]["request_body"])
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// actorKey is the gin context key under which authentication stores the
+	// name of the caller.
+	actorKey       = "actor"
+	anonymousActor = "anonymous"
+
+	maxAuditBody = 64 << 10
+)
+
+// auditLog records every state-changing request in the audit_log table. The
+// record travels in the request context so that store transactions can write
+// it atomically with the business change; otherwise it is written once the
+// response status is known.
+func (server *Server) auditLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			ctx.Next()
+			return
+		}
+
+		actor := ctx.GetString(actorKey)
+		if actor == "" {
+			actor = anonymousActor
+		}
+
+		record := &db.AuditRecord{
+			Actor:       actor,
+			Method:      ctx.Request.Method,
+			Route:       ctx.FullPath(),
+			RequestID:   logging.RequestID(ctx.Request.Context()),
+			ClientIP:    ctx.ClientIP(),
+			RequestBody: readAuditBody(ctx),
+		}
+		for _, param := range ctx.Params {
+			record.AddTarget(param.Key, param.Value)
+		}
+		ctx.Request = ctx.Request.WithContext(db.WithAuditRecord(ctx.Request.Context(), record))
+
+		ctx.Next()
+
+		if record.Written() {
+			return
+		}
+		// the client may be gone, the audit row must still be written
+		auditCtx := context.WithoutCancel(ctx.Request.Context())
+		if err := server.store.WriteAuditRecord(auditCtx, record, ctx.Writer.Status()); err != nil {
+			logging.FromContext(ctx).Error("failed to write audit record", "error", err)
+		}
+	}
+}
+
+// readAuditBody returns the redacted JSON body and restores it for the handler.
+func readAuditBody(ctx *gin.Context) []byte {
+	if ctx.Request.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		// the handler must still see the error, e.g. a body over the size limit
+		ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), failingReader{err}))
+		return nil
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 || len(body) > maxAuditBody {
+		return nil
+	}
+
+	return util.RedactJSON(body)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditLogMiddleware(t *testing.T) {
+	account := randAccount(util.RandomCurrency())
+
+	t.Run("WrittenAfterResponse", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		// anonymous calls to webhooks are rejected, and audited
+		store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Eq(http.StatusUnauthorized)).Times(1).
+			DoAndReturn(func(_ context.Context, record *db.AuditRecord, _ int) error {
+				require.Equal(t, anonymousActor, record.Actor)
+				require.Equal(t, http.MethodPost, record.Method)
+				require.Equal(t, "/webhooks", record.Route)
+				require.Equal(t, "req-1", record.RequestID)
+				require.Equal(t, "10.0.0.1", record.ClientIP)
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(record.RequestBody, &body))
+				require.Equal(t, "[REDACTED]", body["secret"])
+				require.Equal(t, "[REDACTED]", body["nested"].(map[string]any)["password"])
+				require.Equal(t, "not a url", body["url"])
+				return nil
+			})
+
+		server := NewServer(store)
+		recorder := httptest.NewRecorder()
+
+		data, err := json.Marshal(gin.H{
+			"url":    "not a url",
+			"secret": "top",
+			"nested": gin.H{"password": "hunter2"},
+		})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(requestIDHeader, "req-1")
+		req.RemoteAddr = "10.0.0.1:40000"
+
+		server.router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("WrittenInTransaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+				record := db.AuditRecordFrom(ctx)
+				require.NotNil(t, record)
+				// stands in for execTx persisting the record before commit
+				record.MarkWritten()
+				return account, nil
+			})
+		store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		server := NewServer(store)
+		recorder := httptest.NewRecorder()
+
+		data, err := json.Marshal(gin.H{"owner": account.Owner, "currency": account.Currency})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		server.router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("ReadsNotAudited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{account}, nil)
+		store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		server := NewServer(store)
+		recorder := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/accounts?limit=5&page=1", nil)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const authRealm = `Basic realm="simplebank"`
+
+// authenticate identifies callers by the HTTP Basic credentials of a user
+// and stores their name under actorKey. Requests without an Authorization
+// header stay anonymous; any other credentials are rejected with 401.
+func (server *Server) authenticate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+
+		username, password, ok := ctx.Request.BasicAuth()
+		if !ok {
+			unauthenticated(ctx)
+			return
+		}
+
+		user, err := server.store.GetUser(ctx, username)
+		if err == nil {
+			err = util.CheckPassword(password, user.HashedPassword)
+		}
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			unauthenticated(ctx)
+			return
+		}
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+
+		ctx.Set(actorKey, user.Username)
+		ctx.Next()
+	}
+}
+
+// requireActor rejects anonymous requests to routes acting on the caller's
+// own resources.
+func requireActor(ctx *gin.Context) {
+	if ctx.GetString(actorKey) == "" {
+		unauthenticated(ctx)
+		return
+	}
+	ctx.Next()
+}
+
+// UseAdmins sets the users allowed to call the /admin routes. By default no
+// one is.
+func (server *Server) UseAdmins(usernames []string) {
+	server.admins = usernames
+}
+
+// requireAdmin rejects anonymous requests with 401 and requests of users
+// other than the admins with 403.
+func (server *Server) requireAdmin(ctx *gin.Context) {
+	actor := ctx.GetString(actorKey)
+	if actor == "" {
+		unauthenticated(ctx)
+		return
+	}
+	if !slices.Contains(server.admins, actor) {
+		writeError(ctx, apperr.New(apperr.CodePermissionDenied, "admin access is required"))
+		return
+	}
+	ctx.Next()
+}
+
+func unauthenticated(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", authRealm)
+	writeError(ctx, apperr.New(apperr.CodeUnauthenticated, "valid credentials are required"))
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// randomUserWithPassword returns a user and the password it authenticates with.
+func randomUserWithPassword(t *testing.T) (db.User, string) {
+	password := util.RandomOwner()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	return db.User{Username: util.RandomOwner(), HashedPassword: hashedPassword, Email: "user@example.com"}, password
+}
+
+// addAuthorization authenticates req as user and stubs the lookup of user.
+func addAuthorization(req *http.Request, store *mockdb.MockStore, user db.User, password string) {
+	store.EXPECT().GetUser(gomock.Any(), user.Username).AnyTimes().Return(user, nil)
+	req.SetBasicAuth(user.Username, password)
+}
+
+func TestAuthenticate(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(req *http.Request, store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				addAuthorization(req, store, user, password)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, user.Username, recorder.Body.String())
+			},
+		},
+		{
+			name:      "Anonymous",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, authRealm, recorder.Header().Get("WWW-Authenticate"))
+			},
+		},
+		{
+			name: "WrongPassword",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				addAuthorization(req, store, user, "wrong")
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "unauthenticated")
+			},
+		},
+		{
+			name: "UnknownUser",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), "nobody").Times(1).Return(db.User{}, pgx.ErrNoRows)
+				req.SetBasicAuth("nobody", password)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "OtherScheme",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				req.Header.Set("Authorization", "Bearer token")
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			server := NewServer(store)
+			server.router.GET("/auth", requireActor, func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.GetString(actorKey))
+			})
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/auth", nil)
+			require.NoError(t, err)
+			tc.setupAuth(req, store)
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	admin, adminPassword := randomUserWithPassword(t)
+	user, password := randomUserWithPassword(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(req *http.Request, store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				addAuthorization(req, store, admin, adminPassword)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Anonymous",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, authRealm, recorder.Header().Get("WWW-Authenticate"))
+			},
+		},
+		{
+			name: "NotAdmin",
+			setupAuth: func(req *http.Request, store *mockdb.MockStore) {
+				addAuthorization(req, store, user, password)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "permission_denied")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			server := NewServer(store)
+			server.UseAdmins([]string{admin.Username})
+			server.router.GET("/admin-only", server.requireAdmin, func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/admin-only", nil)
+			require.NoError(t, err)
+			tc.setupAuth(req, store)
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"strconv"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+const readYourWritesHeader = "X-Read-Your-Writes"
+
+// readYourWrites sends the reads of requests with "X-Read-Your-Writes: true"
+// to the primary, so a client sees its own recent writes, such as the new
+// balance after a transfer, even while the replicas lag behind.
+func readYourWrites() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if primary, _ := strconv.ParseBool(ctx.GetHeader(readYourWritesHeader)); primary {
+			ctx.Request = ctx.Request.WithContext(db.WithPrimaryReads(ctx.Request.Context()))
+		}
+		ctx.Next()
+	}
+}
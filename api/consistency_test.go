@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	testCases := []struct {
+		name    string
+		header  string
+		primary bool
+	}{
+		{name: "Default", primary: false},
+		{name: "True", header: "true", primary: true},
+		{name: "One", header: "1", primary: true},
+		{name: "False", header: "false", primary: false},
+		{name: "Invalid", header: "please", primary: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).
+				DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+					require.Equal(t, tc.primary, db.PrimaryReads(ctx))
+					return db.Account{ID: id}, nil
+				})
+
+			server := NewServer(store)
+			request := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+			if tc.header != "" {
+				request.Header.Set(readYourWritesHeader, tc.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func TestTransferValidationReadsPrimary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	server := NewServer(store)
+
+	store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			require.True(t, db.PrimaryReads(ctx))
+			return db.Account{ID: id, Status: db.AccountStatusActive, Currency: "USD"}, nil
+		})
+
+	_, err := server.getValidAccount(context.Background(), 1, "USD")
+	require.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamingRoutes hold their response open indefinitely and get no deadline.
+var streamingRoutes = map[string]bool{
+	"/accounts/:id/events": true,
+}
+
+// UseTimeouts sets the deadline of every request. routes overrides it per
+// route, keyed by method and path as registered, e.g. "POST /transfers". A
+// zero timeout means no deadline.
+func (server *Server) UseTimeouts(timeout time.Duration, routes map[string]time.Duration) {
+	server.timeout = timeout
+	server.routeTimeouts = routes
+}
+
+// deadline bounds the request context, and with it every store call made
+// for the request, by the route's timeout.
+func (server *Server) deadline() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		timeout, ok := server.routeTimeouts[ctx.Request.Method+" "+route]
+		if !ok {
+			timeout = server.timeout
+		}
+		if timeout <= 0 || route == "" || streamingRoutes[route] {
+			ctx.Next()
+			return
+		}
+
+		deadlineCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(deadlineCtx)
+
+		ctx.Next()
+	}
+}
+
+// ParseRouteTimeouts parses comma-separated route timeouts such as
+// "POST /transfers=15s,GET /admin/audit_log=1m".
+func ParseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q, want \"METHOD /path=duration\"", entry)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %w", entry, err)
+		}
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = timeout
+	}
+	return routes, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	server := NewServer(store)
+	server.UseTimeouts(20*time.Millisecond, map[string]time.Duration{
+		"GET /accounts": 0,
+	})
+
+	// a query that only ends when its context does
+	store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			_, ok := ctx.Deadline()
+			require.True(t, ok)
+			<-ctx.Done()
+			return db.Account{}, ctx.Err()
+		})
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	require.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "deadline_exceeded")
+
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+			_, ok := ctx.Deadline()
+			require.False(t, ok, "zero route timeout means no deadline")
+			return []db.Account{}, nil
+		})
+
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/accounts?page=1&limit=5", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestTransferValidationUsesRequestContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	server := NewServer(store)
+	server.UseTimeouts(time.Minute, nil)
+
+	store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			_, ok := ctx.Deadline()
+			require.True(t, ok)
+			return db.Account{}, context.Canceled
+		})
+	store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	body, err := json.Marshal(gin.H{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD"})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body)))
+	require.Equal(t, 499, recorder.Code)
+}
+
+func TestParseRouteTimeouts(t *testing.T) {
+	routes, err := ParseRouteTimeouts(" post /transfers=15s, GET /admin/audit_log=1m ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{
+		"POST /transfers":      15 * time.Second,
+		"GET /admin/audit_log": time.Minute,
+	}, routes)
+
+	routes, err = ParseRouteTimeouts("")
+	require.NoError(t, err)
+	require.Empty(t, routes)
+
+	for _, invalid := range []string{"/transfers=1s", "POST /transfers", "POST transfers=1s", "POST /transfers=soon"} {
+		_, err := ParseRouteTimeouts(invalid)
+		require.Error(t, err, invalid)
+	}
+}
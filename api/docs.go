@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/avfirsov/golang-backend-masterclass/docs"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// serveOpenAPI returns the generated OpenAPI 3 document.
+func (server *Server) serveOpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json", docs.OpenAPI)
+}
+
+// serveDocs serves Swagger UI pointed at /openapi.json.
+func (server *Server) serveDocs(ctx *gin.Context) {
+	switch file := ctx.Param("file"); file {
+	case "":
+		ctx.Redirect(http.StatusMovedPermanently, "/docs/")
+	case "/", "/index.html":
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", docs.SwaggerUI)
+	default:
+		ctx.FileFromFS(file, swaggerFiles.HTTP)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	"github.com/avfirsov/golang-backend-masterclass/docs"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// undocumentedRoutes serve the documentation itself or are scraped by
+// Prometheus rather than called by clients.
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+	"GET /docs/*file":   true,
+	"GET /metrics":      true,
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// routeBindings lists, per documented route, the structs its handler binds
+// path and query parameters and the JSON body into. Headers are not bound
+// and are not compared.
+var routeBindings = map[string][]any{
+	"GET /accounts":                         {ListAccountsRequest{}},
+	"POST /accounts":                        {createAccountRequest{}},
+	"GET /accounts/{id}":                    {GetAccountRequest{}},
+	"GET /accounts/{id}/events":             {GetAccountRequest{}},
+	"POST /transfers":                       {transferRequest{}},
+	"POST /webhooks":                        {createWebhookRequest{}},
+	"GET /webhooks/{id}/deliveries":         {listWebhookDeliveriesURI{}, listWebhookDeliveriesQuery{}},
+	"GET /notifications":                    {listNotificationsRequest{}},
+	"POST /notifications/{id}/read":         {notificationURI{}},
+	"GET /notifications/preferences":        nil,
+	"PUT /notifications/preferences":        {updateNotificationPreferenceRequest{}},
+	"GET /admin/accounts/{id}/verify_chain": {verifyChainRequest{}},
+	"PUT /admin/accounts/{id}/status":       {accountURI{}, updateAccountStatusRequest{}},
+	"GET /admin/audit_log":                  {listAuditLogRequest{}},
+	"GET /admin/jobs/failed":                {listFailedJobsRequest{}},
+	"GET /healthz":                          nil,
+	"GET /readyz":                           nil,
+}
+
+// TestOpenAPIMatchesRoutes fails when a route is added, removed or renamed
+// without regenerating the spec with `make openapi`.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	server := NewServer(mockdb.NewMockStore(ctrl))
+
+	var routed []string
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + route.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+		routed = append(routed, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	sort.Strings(documented)
+	sort.Strings(routed)
+	require.Equal(t, routed, documented, "routes and docs/openapi.json differ, run make openapi")
+}
+
+// TestOpenAPIMatchesBindings fails when the path and query parameters or the
+// JSON body of an operation no longer match what its handler binds.
+func TestOpenAPIMatchesBindings(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	require.NoError(t, err)
+
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			key := method + " " + path
+			bindings, ok := routeBindings[key]
+			require.True(t, ok, "add the request structs of %s to routeBindings", key)
+
+			want := boundFields(bindings)
+
+			got := documentedFields{params: map[string]bool{}, body: map[string]bool{}}
+			for _, param := range op.Parameters {
+				if in := param.Value.In; in == openapi3.ParameterInPath || in == openapi3.ParameterInQuery {
+					got.params[in+" "+param.Value.Name] = param.Value.Required
+				}
+			}
+			if op.RequestBody != nil {
+				schema := op.RequestBody.Value.Content.Get("application/json").Schema.Value
+				for name := range schema.Properties {
+					got.body[name] = slices.Contains(schema.Required, name)
+				}
+			}
+
+			require.Equal(t, want, got, "%s differs from its handler's bindings, fix the annotations and run make openapi", key)
+		}
+	}
+}
+
+// documentedFields maps "in name" of parameters and the names of body
+// properties to whether they are required.
+type documentedFields struct {
+	params map[string]bool
+	body   map[string]bool
+}
+
+func boundFields(bindings []any) documentedFields {
+	fields := documentedFields{params: map[string]bool{}, body: map[string]bool{}}
+	for _, binding := range bindings {
+		typ := reflect.TypeOf(binding)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			required := slices.Contains(strings.Split(field.Tag.Get("binding"), ","), "required")
+			if name := tagName(field, "uri"); name != "" {
+				fields.params[openapi3.ParameterInPath+" "+name] = true
+			}
+			if name := tagName(field, "form"); name != "" {
+				fields.params[openapi3.ParameterInQuery+" "+name] = required
+			}
+			if name := tagName(field, "json"); name != "" {
+				fields.body[name] = required
+			}
+		}
+	}
+	return fields
+}
+
+func tagName(field reflect.StructField, key string) string {
+	name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func TestServeDocs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	server := NewServer(mockdb.NewMockStore(ctrl))
+
+	testCases := []struct {
+		url         string
+		code        int
+		contentType string
+	}{
+		{url: "/openapi.json", code: http.StatusOK, contentType: "application/json"},
+		{url: "/docs", code: http.StatusMovedPermanently},
+		{url: "/docs/", code: http.StatusOK, contentType: "text/html"},
+		{url: "/docs/swagger-ui-bundle.js", code: http.StatusOK, contentType: "javascript"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.code, recorder.Code)
+			require.True(t, strings.Contains(recorder.Header().Get("Content-Type"), tc.contentType))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// writeError responds with err as an RFC 7807 problem, with validation
+// messages in the language of Accept-Language. The cause of internal errors
+// is logged and never sent to the client.
+func writeError(ctx *gin.Context, err error) {
+	appErr := apperr.From(err)
+	if appErr.Code == apperr.CodeInternal {
+		logging.FromContext(ctx).Error("internal error", "error", err)
+	}
+	appErr = apperr.Localize(appErr, acceptedLanguage(ctx.GetHeader("Accept-Language")))
+
+	_ = ctx.Error(err)
+	ctx.Header("Content-Type", apperr.ProblemContentType)
+	ctx.AbortWithStatusJSON(appErr.HTTPStatus(), appErr.Problem(ctx.Request.URL.Path))
+}
+
+// fieldName reports request fields by the name clients send them under.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
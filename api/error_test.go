@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProblemResponses(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, problem apperr.Problem)
+	}{
+		{
+			name: "ValidationFields",
+			body: gin.H{"owner": "alice", "currency": "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, problem apperr.Problem) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, apperr.CodeInvalidArgument, problem.Code)
+				require.Equal(t, []apperr.FieldError{
+					{Field: "currency", Rule: "oneof", Message: "must be one of USD, EUR, RUB, CAD"},
+				}, problem.Errors)
+			},
+		},
+		{
+			name: "MalformedJSON",
+			body: nil,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, problem apperr.Problem) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, apperr.CodeInvalidArgument, problem.Code)
+			},
+		},
+		{
+			name: "DuplicateAccount",
+			body: gin.H{"owner": "alice", "currency": "USD"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Account{}, &pgconn.PgError{Code: "23505", ConstraintName: "owner_currency_key"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, problem apperr.Problem) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Equal(t, apperr.CodeAlreadyExists, problem.Code)
+				require.NotContains(t, recorder.Body.String(), "owner_currency_key")
+			},
+		},
+		{
+			name: "UnknownOwner",
+			body: gin.H{"owner": "nobody", "currency": "USD"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Account{}, &pgconn.PgError{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, problem apperr.Problem) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Equal(t, apperr.CodeInvalidReference, problem.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			data := []byte("{")
+			if tc.body != nil {
+				var err error
+				data, err = json.Marshal(tc.body)
+				require.NoError(t, err)
+			}
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, apperr.ProblemContentType, recorder.Header().Get("Content-Type"))
+
+			var problem apperr.Problem
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			require.Equal(t, recorder.Code, problem.Status)
+			require.Equal(t, "/accounts", problem.Instance)
+			tc.checkResponse(t, recorder, problem)
+		})
+	}
+}
+
+func TestLocalizedValidation(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		detail         string
+		errors         []apperr.FieldError
+	}{
+		{
+			name:   "Default",
+			detail: "request validation failed",
+			errors: []apperr.FieldError{
+				{Field: "from_account_id", Rule: "min", Message: "must be at least 1"},
+				{Field: "amount", Rule: "gt", Message: "must be greater than 0"},
+				{Field: "currency", Rule: "currency", Message: "must be a supported currency"},
+			},
+		},
+		{
+			name:           "Russian",
+			acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8",
+			detail:         "запрос не прошёл проверку",
+			errors: []apperr.FieldError{
+				{Field: "from_account_id", Rule: "min", Message: "должно быть не меньше 1"},
+				{Field: "amount", Rule: "gt", Message: "должно быть больше 0"},
+				{Field: "currency", Rule: "currency", Message: "должно быть поддерживаемой валютой"},
+			},
+		},
+		{
+			name:           "Unsupported",
+			acceptLanguage: "de-DE",
+			detail:         "request validation failed",
+			errors: []apperr.FieldError{
+				{Field: "from_account_id", Rule: "min", Message: "must be at least 1"},
+				{Field: "amount", Rule: "gt", Message: "must be greater than 0"},
+				{Field: "currency", Rule: "currency", Message: "must be a supported currency"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"from_account_id": -1, "to_account_id": 2, "amount": -5, "currency": "XYZ"})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.acceptLanguage != "" {
+				request.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			server.router.ServeHTTP(recorder, request)
+
+			var problem apperr.Problem
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+			require.Equal(t, tc.detail, problem.Detail)
+			require.Equal(t, tc.errors, problem.Errors)
+		})
+	}
+}
+
+func TestAcceptedLanguage(t *testing.T) {
+	testCases := []struct {
+		header string
+		lang   string
+	}{
+		{header: "", lang: "en"},
+		{header: "ru", lang: "ru"},
+		{header: "RU-ru", lang: "ru"},
+		{header: "en-US,en;q=0.9,ru;q=0.8", lang: "en"},
+		{header: "de-DE,ru;q=0.5,en;q=0.4", lang: "ru"},
+		{header: "en;q=0.2, ru;q=0.7", lang: "ru"},
+		{header: "ru;q=0, en", lang: "en"},
+		{header: "ru;q=abc", lang: "en"},
+		{header: "*", lang: "en"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			require.Equal(t, tc.lang, acceptedLanguage(tc.header))
+		})
+	}
+}
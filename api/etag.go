@@ -0,0 +1,56 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// accountETag is the strong entity tag of an account: its quoted version.
+func accountETag(account db.Account) string {
+	return strconv.Quote(strconv.FormatInt(account.Version, 10))
+}
+
+// ifMatchVersion returns the account version required by the If-Match
+// header, or 0 for "*". Mutating account endpoints require the header, so
+// that concurrent edits fail instead of overwriting each other. Weak tags
+// never match, as If-Match compares strongly.
+func ifMatchVersion(ctx *gin.Context) (int64, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	switch {
+	case header == "":
+		return 0, apperr.New(apperr.CodePreconditionRequired, "If-Match header with the account ETag is required")
+	case header == "*":
+		return 0, nil
+	case strings.HasPrefix(header, "W/"):
+		return 0, apperr.New(apperr.CodePreconditionFailed, "weak ETag %s does not match", header)
+	}
+
+	tag, err := strconv.Unquote(header)
+	version, parseErr := strconv.ParseInt(tag, 10, 64)
+	if err != nil || parseErr != nil || version < 1 {
+		return 0, apperr.New(apperr.CodePreconditionFailed, "If-Match %s is not an account ETag", header)
+	}
+	return version, nil
+}
+
+// notModified reports whether the If-None-Match header lists etag, comparing
+// weakly as the header requires.
+func notModified(ctx *gin.Context, etag string) bool {
+	for _, tag := range strings.Split(ctx.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAccount responds with account and its ETag.
+func writeAccount(ctx *gin.Context, status int, account db.Account) {
+	ctx.Header("ETag", accountETag(account))
+	ctx.JSON(status, account)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/db/migration"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds every readiness check.
+const readinessTimeout = 2 * time.Second
+
+// probeRoutes are polled by the orchestrator; they are not traced and their
+// successful requests are logged at debug level.
+var probeRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+type healthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// AddReadinessCheck adds a check to /readyz. A failing critical check takes
+// the service out of rotation; any other failing check only degrades it.
+func (server *Server) AddReadinessCheck(name string, check health.Check, critical bool) {
+	server.readiness.Add(name, check, critical)
+}
+
+func (server *Server) addStoreChecks() {
+	server.readiness.Add("database", server.store.Ping, true)
+	server.readiness.Add("migrations", server.checkSchemaVersion, true)
+}
+
+func (server *Server) checkSchemaVersion(ctx context.Context) error {
+	state, err := server.store.GetSchemaMigration(ctx)
+	if err != nil {
+		return err
+	}
+	if err := migration.Check(uint(state.Version), state.Dirty); err != nil {
+		return err
+	}
+	if latest := migration.Latest(); uint(state.Version) < latest {
+		return fmt.Errorf("schema version is %d, want %d", state.Version, latest)
+	}
+	return nil
+}
+
+// healthz reports that the process is alive.
+//
+// @Summary     Liveness probe
+// @Tags        health
+// @Produce     json
+// @Success     200  {object}  healthResponse
+// @Router      /healthz [get]
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, healthResponse{Status: health.StatusOK})
+}
+
+// readyz reports whether the service can serve traffic, with the result of
+// every dependency check.
+//
+// @Summary     Readiness probe
+// @Tags        health
+// @Produce     json
+// @Success     200  {object}  health.Report
+// @Failure     503  {object}  health.Report
+// @Router      /readyz [get]
+func (server *Server) readyz(ctx *gin.Context) {
+	report := server.readiness.Run(ctx)
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/db/migration"
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	server := NewServer(mockdb.NewMockStore(ctrl))
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status": "ok"}`, recorder.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	latest := int64(migration.Latest())
+	current := db.SchemaMigration{Version: latest}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		worker        health.Check
+		checkResponse func(t *testing.T, code int, report health.Report)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(current, nil)
+			},
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusOK, code)
+				require.Equal(t, health.StatusOK, report.Status)
+				require.Len(t, report.Checks, 3)
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(db.SchemaMigration{}, errors.New("connection refused"))
+			},
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusServiceUnavailable, code)
+				require.Equal(t, health.StatusUnavailable, report.Status)
+				require.Equal(t, "connection refused", report.Checks["database"].Error)
+			},
+		},
+		{
+			name: "SchemaBehind",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(db.SchemaMigration{Version: latest - 1}, nil)
+			},
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusServiceUnavailable, code)
+				require.Equal(t, health.StatusFailed, report.Checks["migrations"].Status)
+			},
+		},
+		{
+			name: "SchemaTooNew",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(db.SchemaMigration{Version: latest + 1}, nil)
+			},
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusServiceUnavailable, code)
+				require.Contains(t, report.Checks["migrations"].Error, "newer than this binary")
+			},
+		},
+		{
+			name: "SchemaDirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(db.SchemaMigration{Version: latest, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusServiceUnavailable, code)
+				require.Contains(t, report.Checks["migrations"].Error, "dirty")
+			},
+		},
+		{
+			name: "WorkerFailing",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(current, nil)
+			},
+			worker: func(ctx context.Context) error { return errors.New("last run failed") },
+			checkResponse: func(t *testing.T, code int, report health.Report) {
+				require.Equal(t, http.StatusOK, code)
+				require.Equal(t, health.StatusDegraded, report.Status)
+				require.Equal(t, health.StatusFailed, report.Checks["worker"].Status)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			worker := tc.worker
+			if worker == nil {
+				worker = func(ctx context.Context) error { return nil }
+			}
+			server.AddReadinessCheck("worker", worker, false)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var report health.Report
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+			tc.checkResponse(t, recorder.Code, report)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+)
+
+// subscriberBuffer is how many events a stream may lag behind before it is
+// disconnected; the client then reconnects and catches up via Last-Event-ID.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	events  chan db.AccountEvent
+	dropped chan struct{}
+}
+
+// accountEventHub fans account events received from the database out to the
+// open event streams of each account.
+type accountEventHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[*subscriber]struct{}
+}
+
+func newAccountEventHub() *accountEventHub {
+	return &accountEventHub{subscribers: make(map[int64]map[*subscriber]struct{})}
+}
+
+func (hub *accountEventHub) subscribe(accountID int64) *subscriber {
+	sub := &subscriber{
+		events:  make(chan db.AccountEvent, subscriberBuffer),
+		dropped: make(chan struct{}),
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.subscribers[accountID] == nil {
+		hub.subscribers[accountID] = make(map[*subscriber]struct{})
+	}
+	hub.subscribers[accountID][sub] = struct{}{}
+	return sub
+}
+
+func (hub *accountEventHub) unsubscribe(accountID int64, sub *subscriber) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.remove(accountID, sub)
+}
+
+// publish never blocks: a subscriber whose buffer is full is dropped.
+func (hub *accountEventHub) publish(event db.AccountEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range hub.subscribers[event.AccountID] {
+		select {
+		case sub.events <- event:
+		default:
+			hub.remove(event.AccountID, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// dropAll disconnects every subscriber, used when events may have been missed.
+func (hub *accountEventHub) dropAll() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for accountID, subs := range hub.subscribers {
+		for sub := range subs {
+			close(sub.dropped)
+		}
+		delete(hub.subscribers, accountID)
+	}
+}
+
+func (hub *accountEventHub) remove(accountID int64, sub *subscriber) {
+	subs := hub.subscribers[accountID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(hub.subscribers, accountID)
+	}
+}
+
+// listen feeds the hub from the store until ctx is cancelled, reconnecting
+// after failures.
+func (hub *accountEventHub) listen(ctx context.Context, store db.Store) {
+	for {
+		err := store.ListenAccountEvents(ctx, hub.publish)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Error("account events listener failed", "error", err)
+		hub.dropAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
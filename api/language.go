@@ -0,0 +1,44 @@
+package api
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+)
+
+// acceptedLanguage picks the most preferred language of an Accept-Language
+// header that validation messages are available in, e.g. "ru" for
+// "ru-RU,ru;q=0.9,en;q=0.8". It falls back to English.
+func acceptedLanguage(header string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if quality > 0 && apperr.SupportsLanguage(lang) {
+			preferences = append(preferences, preference{lang: lang, quality: quality})
+		}
+	}
+
+	slices.SortStableFunc(preferences, func(a, b preference) int { return cmp.Compare(b.quality, a.quality) })
+	if len(preferences) == 0 {
+		return apperr.LangEnglish
+	}
+	return preferences[0].lang
+}
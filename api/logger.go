@@ -0,0 +1,71 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestID takes the request ID from the X-Request-ID header or, when it is
+// missing or malformed, assigns a new one, echoes it in the response and
+// attaches it, with a logger tagged by it, to the request context.
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+			ctx.Request.Header.Set(requestIDHeader, id)
+		}
+		ctx.Header(requestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), id))
+
+		ctx.Next()
+	}
+}
+
+// requestLogger writes one access log record per request.
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case probeRoutes[ctx.FullPath()]:
+			level = slog.LevelDebug
+		}
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = ctx.Request.URL.Path
+		}
+
+		logging.FromContext(ctx).LogAttrs(ctx, level, "request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.Int("bytes", ctx.Writer.Size()),
+		)
+	}
+}
+
+// recoverer turns panics into logged internal errors.
+func recoverer() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, recovered any) {
+		logging.FromContext(ctx).Error("panic while handling request", "panic", recovered)
+		writeError(ctx, apperr.New(apperr.CodeInternal, "internal server error"))
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name       string
+		requestID  string
+		propagated bool
+	}{
+		{name: "Propagated", requestID: "req-1", propagated: true},
+		{name: "Assigned"},
+		{name: "TooLong", requestID: strings.Repeat("a", 65)},
+		{name: "InvalidCharacters", requestID: "req-1 level=ERROR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).Return(db.Account{}, pgx.ErrNoRows)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				req.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+
+			got := recorder.Header().Get(requestIDHeader)
+			require.NotEmpty(t, got)
+			if tc.propagated {
+				require.Equal(t, tc.requestID, got)
+			} else {
+				require.NotEqual(t, tc.requestID, got)
+			}
+		})
+	}
+}
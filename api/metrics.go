@@ -0,0 +1,30 @@
+package api
+
+import (
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scanners
+// probing random paths cannot blow up the metric's label set.
+const unmatchedRoute = "unmatched"
+
+// instrument records the latency of every request by route and status.
+func instrument() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveHTTPRequest(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+func (server *Server) serveMetrics(ctx *gin.Context) {
+	metrics.Handler().ServeHTTP(ctx.Writer, ctx.Request)
+}
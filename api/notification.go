@@ -0,0 +1,164 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type listNotificationsRequest struct {
+	Unread bool  `form:"unread"`
+	Limit  int32 `form:"limit" binding:"required,min=5,max=100"`
+	Page   int32 `form:"page" binding:"required,min=1"`
+}
+
+// listNotifications returns the in-app notifications of the caller, newest
+// first.
+//
+// @Summary     List notifications
+// @Tags        notifications
+// @Produce     json
+// @Security    BasicAuth
+// @Param       unread  query     bool  false  "Only notifications not marked as read"
+// @Param       limit   query     int   true   "Page size"  minimum(5)  maximum(100)
+// @Param       page    query     int   true   "Page number"  minimum(1)
+// @Success     200     {array}   db.Notification
+// @Failure     400     {object}  apperr.Problem
+// @Failure     401     {object}  apperr.Problem
+// @Failure     500     {object}  apperr.Problem
+// @Router      /notifications [get]
+func (server *Server) listNotifications(ctx *gin.Context) {
+	var req listNotificationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	notifications, err := server.store.ListNotifications(ctx, db.ListNotificationsParams{
+		Username:   ctx.GetString(actorKey),
+		UnreadOnly: req.Unread,
+		Limit:      req.Limit,
+		Offset:     (req.Page - 1) * req.Limit,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, notifications)
+}
+
+type notificationURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// markNotificationRead marks a notification of the caller as read. Marking
+// it again keeps the first read time.
+//
+// @Summary     Mark notification as read
+// @Tags        notifications
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id   path      int64  true  "Notification ID"
+// @Success     200  {object}  db.Notification
+// @Failure     400  {object}  apperr.Problem
+// @Failure     401  {object}  apperr.Problem
+// @Failure     404  {object}  apperr.Problem  "no such notification for the caller"
+// @Failure     500  {object}  apperr.Problem
+// @Router      /notifications/{id}/read [post]
+func (server *Server) markNotificationRead(ctx *gin.Context) {
+	var uri notificationURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	notification, err := server.store.MarkNotificationRead(ctx, db.MarkNotificationReadParams{
+		ID:       uri.ID,
+		Username: ctx.GetString(actorKey),
+	})
+	if err != nil {
+		writeError(ctx, apperr.NotFoundAs(err, "notification %d", uri.ID))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, notification)
+}
+
+// listNotificationPreferences returns the preferences of the caller for every
+// event, defaults included.
+//
+// @Summary     List notification preferences
+// @Tags        notifications
+// @Produce     json
+// @Security    BasicAuth
+// @Success     200  {array}   db.NotificationPreference
+// @Failure     401  {object}  apperr.Problem
+// @Failure     500  {object}  apperr.Problem
+// @Router      /notifications/preferences [get]
+func (server *Server) listNotificationPreferences(ctx *gin.Context) {
+	username := ctx.GetString(actorKey)
+
+	stored, err := server.store.ListNotificationPreferences(ctx, username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	preferences := make([]db.NotificationPreference, 0, len(db.NotificationEventTypes))
+	for _, eventType := range db.NotificationEventTypes {
+		preference := db.DefaultNotificationPreference(username, eventType)
+		for _, p := range stored {
+			if p.EventType == eventType {
+				preference = p
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+type updateNotificationPreferenceRequest struct {
+	EventType string `json:"event_type" binding:"required,oneof=transfer_sent transfer_received"`
+	Email     *bool  `json:"email" binding:"required"`
+	InApp     *bool  `json:"in_app" binding:"required"`
+	MinAmount int64  `json:"min_amount" binding:"min=0"`
+}
+
+// updateNotificationPreference sets how the caller is notified of an event.
+//
+// @Summary     Update notification preference
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Security    BasicAuth
+// @Param       request  body      updateNotificationPreferenceRequest  true  "Preference to set"
+// @Success     200      {object}  db.NotificationPreference
+// @Failure     400      {object}  apperr.Problem
+// @Failure     401      {object}  apperr.Problem
+// @Failure     500      {object}  apperr.Problem
+// @Router      /notifications/preferences [put]
+func (server *Server) updateNotificationPreference(ctx *gin.Context) {
+	var req updateNotificationPreferenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	preference, err := server.store.UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+		Username:  ctx.GetString(actorKey),
+		EventType: req.EventType,
+		Email:     *req.Email,
+		InApp:     *req.InApp,
+		MinAmount: req.MinAmount,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preference)
+}
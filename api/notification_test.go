@@ -0,0 +1,360 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListNotificationsAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+	notifications := []db.Notification{{
+		ID:         4,
+		Username:   user.Username,
+		EventType:  db.NotificationTransferReceived,
+		TransferID: 9,
+		Title:      "Transfer received",
+		Body:       "100 USD was received on account 2 from account 1.",
+	}}
+
+	testCases := []struct {
+		name          string
+		query         string
+		anonymous     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Unread",
+			// the username parameter is ignored, callers only see their own
+			query: "username=someone_else&unread=true&limit=5&page=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), db.ListNotificationsParams{
+					Username:   user.Username,
+					UnreadOnly: true,
+					Limit:      5,
+					Offset:     5,
+				}).Times(1).Return(notifications, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.Notification
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, notifications, got)
+			},
+		},
+		{
+			name:      "Anonymous",
+			query:     "limit=5&page=1",
+			anonymous: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingLimit",
+			query: "page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/notifications?"+tc.query, nil)
+			require.NoError(t, err)
+			if !tc.anonymous {
+				addAuthorization(req, store, user, password)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestMarkNotificationReadAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+
+	testCases := []struct {
+		name          string
+		id            int64
+		anonymous     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   4,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), db.MarkNotificationReadParams{ID: 4, Username: user.Username}).
+					Times(1).Return(db.Notification{ID: 4, Username: user.Username}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   4,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Any()).Times(1).Return(db.Notification{}, pgx.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "Anonymous",
+			id:        4,
+			anonymous: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/notifications/%d/read", tc.id)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			if !tc.anonymous {
+				addAuthorization(req, store, user, password)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListNotificationPreferencesAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+	received := db.NotificationPreference{
+		Username:  user.Username,
+		EventType: db.NotificationTransferReceived,
+		InApp:     true,
+		MinAmount: 1000,
+	}
+
+	testCases := []struct {
+		name          string
+		anonymous     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListNotificationPreferences(gomock.Any(), user.Username).Times(1).
+					Return([]db.NotificationPreference{received}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.NotificationPreference
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, []db.NotificationPreference{
+					db.DefaultNotificationPreference(user.Username, db.NotificationTransferSent),
+					received,
+				}, got)
+			},
+		},
+		{
+			name:      "Anonymous",
+			anonymous: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/notifications/preferences", nil)
+			require.NoError(t, err)
+			if !tc.anonymous {
+				addAuthorization(req, store, user, password)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateNotificationPreferenceAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		anonymous     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username":   "someone_else",
+				"event_type": db.NotificationTransferSent,
+				"email":      false,
+				"in_app":     true,
+				"min_amount": 500,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertNotificationPreferenceParams{
+					Username:  user.Username,
+					EventType: db.NotificationTransferSent,
+					Email:     false,
+					InApp:     true,
+					MinAmount: 500,
+				}
+				store.EXPECT().UpsertNotificationPreference(gomock.Any(), arg).Times(1).
+					Return(db.NotificationPreference{Username: user.Username, EventType: arg.EventType, InApp: true, MinAmount: 500}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Anonymous",
+			body: gin.H{
+				"event_type": db.NotificationTransferSent,
+				"email":      true,
+				"in_app":     true,
+			},
+			anonymous: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreference(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingChannel",
+			body: gin.H{
+				"event_type": db.NotificationTransferSent,
+				"in_app":     true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreference(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeMinAmount",
+			body: gin.H{
+				"event_type": db.NotificationTransferReceived,
+				"email":      true,
+				"in_app":     true,
+				"min_amount": -1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreference(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEvent",
+			body: gin.H{
+				"event_type": "account_frozen",
+				"email":      true,
+				"in_app":     true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreference(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPut, "/notifications/preferences", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if !tc.anonymous {
+				addAuthorization(req, store, user, password)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
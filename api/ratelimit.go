@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// UseRateLimiter limits requests per route class and client IP. Without a
+// limiter requests are not limited.
+func (server *Server) UseRateLimiter(limiter *ratelimit.Limiter) {
+	server.limiter = limiter
+}
+
+// UseTrustedProxies sets the IPs and CIDRs of reverse proxies whose
+// X-Forwarded-For entries are believed when telling the client IP. By default
+// none are, and the client IP is the address of the connection.
+func (server *Server) UseTrustedProxies(proxies []string) error {
+	return server.router.SetTrustedProxies(proxies)
+}
+
+// rateLimit rejects clients over the limit of the route's class with 429.
+// It runs before authenticate, so that credentials are not checked for
+// clients over the limit, and therefore keys by client IP. Probes, metrics
+// scrapes and unknown routes are not limited.
+func (server *Server) rateLimit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if server.limiter == nil || route == "" || route == "/metrics" || probeRoutes[route] {
+			ctx.Next()
+			return
+		}
+
+		result := server.limiter.Allow(ctx, routeClass(ctx.Request, route), "ip:"+ctx.ClientIP())
+		for key, values := range result.Header() {
+			ctx.Writer.Header()[key] = values
+		}
+		if !result.Allowed {
+			writeError(ctx, apperr.New(apperr.CodeRateLimited, "rate limit exceeded"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// routeClass returns the class of a request. Requests carrying credentials
+// are in the login class whatever the route, failed ones included, since
+// authenticate hashes the password of each.
+func routeClass(req *http.Request, route string) ratelimit.Class {
+	switch method := req.Method; {
+	case req.Header.Get("Authorization") != "":
+		return ratelimit.ClassLogin
+	case route == "/transfers":
+		return ratelimit.ClassTransfers
+	case method == http.MethodGet || method == http.MethodHead:
+		return ratelimit.ClassReads
+	default:
+		return ratelimit.ClassWrites
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), int64(1)).Times(1).Return(db.Account{ID: 1}, nil)
+
+	server := NewServer(store)
+	server.UseRateLimiter(ratelimit.New(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassReads: ratelimit.PerMinute(60, 1),
+	}))
+
+	get := func(url, ip string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, url, nil)
+		request.RemoteAddr = ip + ":1234"
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := get("/accounts/1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+
+	recorder = get("/accounts/1", "10.0.0.1")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	require.Contains(t, recorder.Body.String(), "rate_limited")
+
+	recorder = get("/healthz", "10.0.0.1")
+	require.Equal(t, http.StatusOK, recorder.Code, "probes are not limited")
+	require.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	testCases := []struct {
+		name    string
+		proxies []string
+		limited bool
+	}{
+		// the client rotates X-Forwarded-For, which only a trusted proxy may set
+		{name: "UntrustedPeer", limited: true},
+		{name: "TrustedProxy", proxies: []string{"10.0.0.0/8"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), int64(1)).AnyTimes().Return(db.Account{ID: 1}, nil)
+
+			server := NewServer(store)
+			require.NoError(t, server.UseTrustedProxies(tc.proxies))
+			server.UseRateLimiter(ratelimit.New(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+				ratelimit.ClassReads: ratelimit.PerMinute(60, 1),
+			}))
+
+			var codes []int
+			for _, forwarded := range []string{"203.0.113.1", "203.0.113.2"} {
+				request := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+				request.RemoteAddr = "10.0.0.1:1234"
+				request.Header.Set("X-Forwarded-For", forwarded)
+				recorder := httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+				codes = append(codes, recorder.Code)
+			}
+
+			if tc.limited {
+				require.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+			} else {
+				require.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+			}
+		})
+	}
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	user, _ := randomUserWithPassword(t)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	// credentials are not checked once the client is over the limit
+	store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+
+	server := NewServer(store)
+	server.UseRateLimiter(ratelimit.New(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassLogin: ratelimit.PerMinute(60, 1),
+	}))
+
+	var codes []int
+	for range 2 {
+		request := httptest.NewRequest(http.MethodGet, "/notifications?limit=5&page=1", nil)
+		request.SetBasicAuth(user.Username, "wrong")
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+	require.Equal(t, []int{http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+}
+
+func TestRouteClass(t *testing.T) {
+	request := func(method string, credentials bool) *http.Request {
+		req := httptest.NewRequest(method, "/", nil)
+		if credentials {
+			req.SetBasicAuth("alice", "secret")
+		}
+		return req
+	}
+
+	require.Equal(t, ratelimit.ClassTransfers, routeClass(request(http.MethodPost, false), "/transfers"))
+	require.Equal(t, ratelimit.ClassReads, routeClass(request(http.MethodGet, false), "/accounts/:id"))
+	require.Equal(t, ratelimit.ClassWrites, routeClass(request(http.MethodPost, false), "/accounts"))
+	require.Equal(t, ratelimit.ClassLogin, routeClass(request(http.MethodGet, true), "/notifications"))
+	require.Equal(t, ratelimit.ClassLogin, routeClass(request(http.MethodPost, true), "/transfers"))
+}
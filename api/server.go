@@ -1,38 +1,137 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
 	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"github.com/avfirsov/golang-backend-masterclass/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	store      db.Store
+	router     *gin.Engine
+	hub        *accountEventHub
+	readiness  *health.Checker
+	limiter    *ratelimit.Limiter
+	admins     []string
+	mu         sync.Mutex
+	httpServer *http.Server
+
+	timeout       time.Duration
+	routeTimeouts map[string]time.Duration
+
+	// stopping is cancelled when shutdown begins; it ends the event hub
+	// listener and open event streams, which would otherwise hold shutdown
+	// until its deadline.
+	stopping context.Context
+	stop     context.CancelFunc
 }
 
 func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+	server := &Server{
+		store:     store,
+		hub:       newAccountEventHub(),
+		readiness: health.NewChecker(readinessTimeout),
+	}
+	server.addStoreChecks()
+	server.stopping, server.stop = context.WithCancel(context.Background())
+	router := gin.New()
+	// lets store calls made with *gin.Context see values of the request context
+	router.ContextWithFallback = true
+	// forwarding headers are spoofable unless UseTrustedProxies says otherwise
+	router.SetTrustedProxies(nil)
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", ValidCurrency)
+		v.RegisterTagNameFunc(fieldName)
 	}
 
+	router.Use(traced(), requestID(), requestLogger(), instrument(), recoverer(), server.rateLimit(), server.authenticate(), server.auditLog(), server.deadline(), readYourWrites())
+
 	router.POST("/accounts", server.createAccount)
 	router.GET("/accounts/:id", server.getAccount)
 	router.GET("/accounts", server.listAccounts)
+	router.GET("/accounts/:id/events", server.streamAccountEvents)
 	router.POST("/transfers", server.createTransfer)
+	router.POST("/webhooks", requireActor, server.createWebhook)
+	router.GET("/webhooks/:id/deliveries", requireActor, server.listWebhookDeliveries)
+	router.GET("/notifications", requireActor, server.listNotifications)
+	router.POST("/notifications/:id/read", requireActor, server.markNotificationRead)
+	router.GET("/notifications/preferences", requireActor, server.listNotificationPreferences)
+	router.PUT("/notifications/preferences", requireActor, server.updateNotificationPreference)
+
+	router.GET("/openapi.json", server.serveOpenAPI)
+	router.GET("/docs", server.serveDocs)
+	router.GET("/docs/*file", server.serveDocs)
+	router.GET("/metrics", server.serveMetrics)
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+
+	admin := router.Group("/admin", server.requireAdmin)
+	admin.GET("/accounts/:id/verify_chain", server.verifyAccountChain)
+	admin.PUT("/accounts/:id/status", server.updateAccountStatus)
+	admin.GET("/audit_log", server.listAuditLog)
+	admin.GET("/jobs/failed", server.listFailedJobs)
 
 	server.router = router
 	return server
 }
 
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+// Start serves the API on config.ServerAddress until Shutdown is called,
+// after which it returns nil.
+func (server *Server) Start(config util.Config) error {
+	var handler http.Handler = server.router
+	if config.HTTPMaxBodyBytes > 0 {
+		handler = http.MaxBytesHandler(handler, config.HTTPMaxBodyBytes)
+	}
+
+	httpServer := &http.Server{
+		Addr:              config.ServerAddress,
+		Handler:           handler,
+		ReadTimeout:       config.HTTPReadTimeout,
+		ReadHeaderTimeout: config.HTTPReadHeaderTimeout,
+		WriteTimeout:      config.HTTPWriteTimeout,
+		IdleTimeout:       config.HTTPIdleTimeout,
+		MaxHeaderBytes:    config.HTTPMaxHeaderBytes,
+	}
+	httpServer.RegisterOnShutdown(server.stop)
+
+	server.mu.Lock()
+	if server.stopping.Err() != nil {
+		server.mu.Unlock()
+		return nil
+	}
+	server.httpServer = httpServer
+	server.mu.Unlock()
+
+	go server.hub.listen(server.stopping, server.store)
+
+	err := httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown stops accepting connections, ends event streams and waits for
+// in-flight requests to finish or ctx to expire.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.mu.Lock()
+	server.stop()
+	httpServer := server.httpServer
+	server.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServerStartShutdown(t *testing.T) {
+	account := randAccount(util.USD)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).AnyTimes().Return(account, nil)
+	store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), http.StatusRequestEntityTooLarge).Times(1)
+	store.EXPECT().ListenAccountEvents(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, _ func(db.AccountEvent)) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	config := util.Config{
+		ServerAddress:    freeAddress(t),
+		HTTPWriteTimeout: time.Second,
+		HTTPMaxBodyBytes: 16,
+	}
+	server := NewServer(store)
+	started := make(chan error, 1)
+	go func() { started <- server.Start(config) }()
+
+	baseURL := "http://" + config.ServerAddress
+	require.Eventually(t, func() bool {
+		rsp, err := http.Get(fmt.Sprintf("%s/accounts/%d", baseURL, account.ID))
+		if err != nil {
+			return false
+		}
+		rsp.Body.Close()
+		return rsp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rsp, err := http.Post(baseURL+"/accounts", "application/json", strings.NewReader(`{"owner": "a very long owner name", "currency": "USD"}`))
+	require.NoError(t, err)
+	rsp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, rsp.StatusCode)
+
+	// an open event stream outlives the write timeout and does not hold up shutdown
+	stream, err := http.Get(fmt.Sprintf("%s/accounts/%d/events", baseURL, account.ID))
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+	_, err = bufio.NewReader(stream.Body).ReadString('\n')
+	require.NoError(t, err)
+	time.Sleep(config.HTTPWriteTimeout + 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+	require.NoError(t, <-started)
+}
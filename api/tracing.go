@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/avfirsov/golang-backend-masterclass/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// traced starts a span per request, continuing the trace of incoming W3C
+// trace context headers. Prometheus scrapes and probes are not traced.
+func traced() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(func(ctx *gin.Context) bool {
+		return ctx.FullPath() != "/metrics" && !probeRoutes[ctx.FullPath()]
+	}))
+}
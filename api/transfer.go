@@ -2,13 +2,12 @@ package api
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"net/http"
 
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
 	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 )
 
 type transferRequest struct {
@@ -18,30 +17,35 @@ type transferRequest struct {
 	Currency      string `json:"currency" binding:"required,currency"`
 }
 
+// createTransfer moves money between two active accounts of the same currency.
+//
+// @Summary     Create transfer
+// @Tags        transfers
+// @Accept      json
+// @Produce     json
+// @Param       request  body      transferRequest  true  "Transfer to make"
+// @Success     200      {object}  db.TransferTxResult
+// @Failure     400      {object}  apperr.Problem
+// @Failure     404      {object}  apperr.Problem
+// @Failure     409      {object}  apperr.Problem  "concurrent update, safe to retry"
+// @Failure     500      {object}  apperr.Problem
+// @Router      /transfers [post]
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apperr.Invalid(err))
 		return
 	}
 
-	if err := server.validAccountFrom(req.FromAccountID, req.Currency, req.Amount); err != nil {
-		fmt.Println("validAccountFrom error", err)
-		if errors.Is(err, pgx.ErrNoRows) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-		} else {
-			ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		}
+	if err := server.validAccountFrom(ctx, req.FromAccountID, req.Currency, req.Amount); err != nil {
+		logging.FromContext(ctx).Info("transfer rejected", "from_account_id", req.FromAccountID, "error", err)
+		writeError(ctx, err)
 		return
 	}
 
-	if err := server.validAccountTo(req.ToAccountID, req.Currency); err != nil {
-		fmt.Println("validAccountTo error", err)
-		if errors.Is(err, pgx.ErrNoRows) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-		} else {
-			ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		}
+	if err := server.validAccountTo(ctx, req.ToAccountID, req.Currency); err != nil {
+		logging.FromContext(ctx).Info("transfer rejected", "to_account_id", req.ToAccountID, "error", err)
+		writeError(ctx, err)
 		return
 	}
 
@@ -53,40 +57,40 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		fmt.Println("TransferTx error", err)
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (server *Server) validAccountFrom(accountId int64, currency string, amount int64) error {
-	account, err := server.getValidAccount(accountId, currency)
+func (server *Server) validAccountFrom(ctx context.Context, accountId int64, currency string, amount int64) error {
+	account, err := server.getValidAccount(ctx, accountId, currency)
 	if err != nil {
 		return err
 	}
 
 	if account.Balance < amount {
-		return fmt.Errorf("account [%d] balance %d is less than request amount %d", accountId, account.Balance, amount)
+		return apperr.New(apperr.CodeFailedPrecondition, "account [%d] balance %d is less than request amount %d", accountId, account.Balance, amount)
 	}
 
 	return nil
 }
 
-func (server *Server) validAccountTo(accountId int64, currency string) error {
-	_, err := server.getValidAccount(accountId, currency)
+func (server *Server) validAccountTo(ctx context.Context, accountId int64, currency string) error {
+	_, err := server.getValidAccount(ctx, accountId, currency)
 	return err
 }
 
-func (server *Server) getValidAccount(accountId int64, currency string) (db.Account, error) {
-	account, err := server.store.GetAccount(context.Background(), accountId)
+func (server *Server) getValidAccount(ctx context.Context, accountId int64, currency string) (db.Account, error) {
+	// validated on the primary: a replica may not have the account yet
+	account, err := server.store.GetAccount(db.WithPrimaryReads(ctx), accountId)
 	if err != nil {
-		return db.Account{}, err
+		return db.Account{}, apperr.NotFoundAs(err, "account [%d]", accountId)
 	}
 
 	if account.Currency != currency {
-		return db.Account{}, fmt.Errorf("account [%d] currency %s does not match request currency %s", accountId, account.Currency, currency)
+		return db.Account{}, apperr.InvalidField("currency", "currency_mismatch", "account [%d] currency %s does not match request currency %s", accountId, account.Currency, currency)
 	}
 
 	return account, nil
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
 	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
 	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
 	"github.com/avfirsov/golang-backend-masterclass/util"
@@ -152,6 +153,24 @@ func TestCreateTransferAPI(t *testing.T) {
 				requireBodyMatchTransfer(t, recorder, db.TransferTxResult{}, http.StatusBadRequest)
 			},
 		},
+		{
+			name: "FromAccountFrozen",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, apperr.New(apperr.CodeFailedPrecondition, "account %d is %s", fromAccount.ID, db.AccountStatusFrozen))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireBodyMatchTransfer(t, recorder, db.TransferTxResult{}, http.StatusBadRequest)
+			},
+		},
 		{
 			name: "InvalidRequest",
 			body: gin.H{
@@ -192,6 +211,7 @@ func TestCreateTransferAPI(t *testing.T) {
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 			server := NewServer(store)
 			recorder := httptest.NewRecorder()
@@ -209,7 +229,6 @@ func TestCreateTransferAPI(t *testing.T) {
 	}
 }
 
-
 func requireBodyMatchTransfer(t *testing.T, recorder *httptest.ResponseRecorder, result db.TransferTxResult, expectedCode int) {
 	data, err := io.ReadAll(recorder.Body)
 	require.NoError(t, err)
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type createWebhookRequest struct {
+	URL       string `json:"url" binding:"required,url"`
+	EventType string `json:"event_type" binding:"required,oneof=TransferCreated AccountCreated AccountActivated AccountFrozen AccountClosed"`
+}
+
+// createWebhook registers an endpoint of the caller for one event type. The
+// endpoint must resolve to public addresses only. The signing secret is only
+// returned in this response.
+//
+// @Summary     Create webhook
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Security    BasicAuth
+// @Param       request  body      createWebhookRequest  true  "Subscription to create"
+// @Success     200      {object}  db.WebhookSubscription
+// @Failure     400      {object}  apperr.Problem
+// @Failure     401      {object}  apperr.Problem
+// @Failure     500      {object}  apperr.Problem
+// @Router      /webhooks [post]
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	if err := webhooks.CheckURL(ctx, req.URL); err != nil {
+		writeError(ctx, apperr.InvalidField("url", "public_url", "must resolve to a public address"))
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	subscription, err := server.store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:     ctx.GetString(actorKey),
+		Url:       req.URL,
+		EventType: req.EventType,
+		Secret:    secret,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if record := db.AuditRecordFrom(ctx); record != nil {
+		record.AddTarget("webhook", subscription.ID)
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+type listWebhookDeliveriesURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listWebhookDeliveriesQuery struct {
+	Limit int32 `form:"limit" binding:"required,min=5,max=100"`
+	Page  int32 `form:"page" binding:"required,min=1"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus *int32          `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	rsp := webhookDeliveryResponse{
+		ID:            delivery.ID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt.Time,
+		CreatedAt:     delivery.CreatedAt.Time,
+	}
+	if delivery.ResponseStatus.Valid {
+		rsp.ResponseStatus = &delivery.ResponseStatus.Int32
+	}
+	if delivery.LastError.Valid {
+		rsp.LastError = &delivery.LastError.String
+	}
+	if delivery.DeliveredAt.Valid {
+		rsp.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return rsp
+}
+
+// listWebhookDeliveries returns the delivery log of a subscription of the
+// caller, newest first.
+//
+// @Summary     List webhook deliveries
+// @Tags        webhooks
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id     path      int64  true  "Subscription ID"
+// @Param       limit  query     int    true  "Page size"  minimum(5)  maximum(100)
+// @Param       page   query     int    true  "Page number"  minimum(1)
+// @Success     200    {array}   webhookDeliveryResponse
+// @Failure     400    {object}  apperr.Problem
+// @Failure     401    {object}  apperr.Problem
+// @Failure     404    {object}  apperr.Problem
+// @Failure     500    {object}  apperr.Problem
+// @Router      /webhooks/{id}/deliveries [get]
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri listWebhookDeliveriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	var req listWebhookDeliveriesQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apperr.Invalid(err))
+		return
+	}
+
+	subscription, err := server.store.GetWebhookSubscription(ctx, uri.ID)
+	if err == nil && subscription.Owner != ctx.GetString(actorKey) {
+		// other callers' subscriptions are not revealed to exist
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		writeError(ctx, apperr.NotFoundAs(err, "webhook %d", uri.ID))
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: uri.ID,
+		Limit:          req.Limit,
+		Offset:         (req.Page - 1) * req.Limit,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		rsp = append(rsp, newWebhookDeliveryResponse(delivery))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
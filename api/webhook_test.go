@@ -0,0 +1,284 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateWebhookAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		anonymous     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"owner":      "someone_else",
+				"url":        "https://93.184.215.14/hooks",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, "https://93.184.215.14/hooks", arg.Url)
+						require.Len(t, arg.Secret, 64)
+						return db.WebhookSubscription{ID: 1, Owner: arg.Owner, Url: arg.Url, EventType: arg.EventType, Secret: arg.Secret}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.WebhookSubscription
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(1), got.ID)
+				require.NotEmpty(t, got.Secret)
+			},
+		},
+		{
+			name: "Anonymous",
+			body: gin.H{
+				"url":        "https://93.184.215.14/hooks",
+				"event_type": db.EventTransferCreated,
+			},
+			anonymous: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			body: gin.H{
+				"url":        "not a url",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Loopback",
+			body: gin.H{
+				"url":        "http://localhost:8080/hooks",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "public_url")
+			},
+		},
+		{
+			name: "LinkLocal",
+			body: gin.H{
+				"url":        "http://169.254.169.254/latest/meta-data",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "public_url")
+			},
+		},
+		{
+			name: "Private",
+			body: gin.H{
+				"url":        "https://10.0.0.5/hooks",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "public_url")
+			},
+		},
+		{
+			name: "UnsupportedScheme",
+			body: gin.H{
+				"url":        "ftp://93.184.215.14/hooks",
+				"event_type": db.EventTransferCreated,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "public_url")
+			},
+		},
+		{
+			name: "InvalidEventType",
+			body: gin.H{
+				"url":        "https://93.184.215.14/hooks",
+				"event_type": "Everything",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if !tc.anonymous {
+				addAuthorization(req, store, user, password)
+			}
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, password := randomUserWithPassword(t)
+	subscription := db.WebhookSubscription{ID: 3, Owner: user.Username, EventType: db.EventTransferCreated}
+	deliveries := []db.WebhookDelivery{
+		{
+			ID:             2,
+			SubscriptionID: subscription.ID,
+			EventID:        11,
+			EventType:      db.EventTransferCreated,
+			Payload:        []byte(`{"transfer_id":11}`),
+			Status:         "dead",
+			Attempts:       8,
+			ResponseStatus: pgtype.Int4{Int32: 500, Valid: true},
+			LastError:      pgtype.Text{String: "endpoint responded with status 500", Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		subscription  int64
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			subscription: subscription.ID,
+			query:        "limit=5&page=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), db.ListWebhookDeliveriesParams{
+					SubscriptionID: subscription.ID,
+					Limit:          5,
+					Offset:         5,
+				}).Times(1).Return(deliveries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []webhookDeliveryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 1)
+				require.Equal(t, "dead", got[0].Status)
+				require.Equal(t, int32(500), *got[0].ResponseStatus)
+				require.JSONEq(t, `{"transfer_id":11}`, string(got[0].Payload))
+				require.Nil(t, got[0].DeliveredAt)
+			},
+		},
+		{
+			name:         "NotFound",
+			subscription: 404,
+			query:        "limit=5&page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(int64(404))).Times(1).Return(db.WebhookSubscription{}, pgx.ErrNoRows)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "OtherOwner",
+			subscription: subscription.ID,
+			query:        "limit=5&page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				other := subscription
+				other.Owner = "someone_else"
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(other, nil)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "InvalidQuery",
+			subscription: subscription.ID,
+			query:        "limit=1000&page=1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewServer(store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d/deliveries?%s", tc.subscription, tc.query)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(req, store, user, password)
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
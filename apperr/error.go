@@ -0,0 +1,272 @@
+// Package apperr defines the error model shared by the HTTP and gRPC APIs:
+// stable machine-readable codes, their HTTP and gRPC statuses and the
+// mapping from store and Postgres errors.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Code is a stable, machine-readable error code. Clients may switch on it.
+type Code string
+
+const (
+	CodeInvalidArgument      Code = "invalid_argument"
+	CodeUnauthenticated      Code = "unauthenticated"
+	CodePermissionDenied     Code = "permission_denied"
+	CodeFailedPrecondition   Code = "failed_precondition"
+	CodeNotFound             Code = "not_found"
+	CodeAlreadyExists        Code = "already_exists"
+	CodeInvalidReference     Code = "invalid_reference"
+	CodeConflict             Code = "conflict"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodePreconditionRequired Code = "precondition_required"
+	CodeCanceled             Code = "canceled"
+	CodeDeadlineExceeded     Code = "deadline_exceeded"
+	CodeRequestTooLarge      Code = "request_too_large"
+	CodeRateLimited          Code = "rate_limited"
+	CodeInternal             Code = "internal"
+)
+
+// Postgres SQLSTATEs with a dedicated code.
+const (
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	checkViolation       = "23514"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	queryCanceled        = "57014"
+)
+
+// errorDomain identifies ErrorInfo details written by this package.
+const errorDomain = "simplebank"
+
+// statusClientClosedRequest is the non-standard status used when the client
+// went away before the response was written.
+const statusClientClosedRequest = 499
+
+var httpStatuses = map[Code]int{
+	CodeInvalidArgument:      http.StatusBadRequest,
+	CodeUnauthenticated:      http.StatusUnauthorized,
+	CodePermissionDenied:     http.StatusForbidden,
+	CodeFailedPrecondition:   http.StatusBadRequest,
+	CodeNotFound:             http.StatusNotFound,
+	CodeAlreadyExists:        http.StatusConflict,
+	CodeInvalidReference:     http.StatusUnprocessableEntity,
+	CodeConflict:             http.StatusConflict,
+	CodePreconditionFailed:   http.StatusPreconditionFailed,
+	CodePreconditionRequired: http.StatusPreconditionRequired,
+	CodeCanceled:             statusClientClosedRequest,
+	CodeDeadlineExceeded:     http.StatusGatewayTimeout,
+	CodeRequestTooLarge:      http.StatusRequestEntityTooLarge,
+	CodeRateLimited:          http.StatusTooManyRequests,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+var grpcCodes = map[Code]codes.Code{
+	CodeInvalidArgument:      codes.InvalidArgument,
+	CodeUnauthenticated:      codes.Unauthenticated,
+	CodePermissionDenied:     codes.PermissionDenied,
+	CodeFailedPrecondition:   codes.FailedPrecondition,
+	CodeNotFound:             codes.NotFound,
+	CodeAlreadyExists:        codes.AlreadyExists,
+	CodeInvalidReference:     codes.FailedPrecondition,
+	CodeConflict:             codes.Aborted,
+	CodePreconditionFailed:   codes.FailedPrecondition,
+	CodePreconditionRequired: codes.FailedPrecondition,
+	CodeCanceled:             codes.Canceled,
+	CodeDeadlineExceeded:     codes.DeadlineExceeded,
+	CodeRequestTooLarge:      codes.ResourceExhausted,
+	CodeRateLimited:          codes.ResourceExhausted,
+	CodeInternal:             codes.Internal,
+}
+
+// HTTPStatus returns the HTTP status for code.
+func (code Code) HTTPStatus() int {
+	if s, ok := httpStatuses[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC status code for code.
+func (code Code) GRPCCode() codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	return codes.Internal
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is an error safe to show to clients. Message never contains the
+// text of the underlying error, which is kept for logging only.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus returns the HTTP status for the error's code.
+func (e *Error) HTTPStatus() int {
+	return e.Code.HTTPStatus()
+}
+
+// GRPCStatus lets gRPC report the error with its code. The stable code is
+// attached as ErrorInfo and field errors as a BadRequest detail, so FromStatus
+// can restore the error on the other side.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Code.GRPCCode(), e.Message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(e.Code), Domain: errorDomain}}
+	if len(e.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(e.Fields))
+		for _, field := range e.Fields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+				Reason:      field.Rule,
+			})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if detailed, err := st.WithDetails(details...); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// FromStatus converts a gRPC status back into an Error. Statuses that did
+// not originate from an Error get the code closest to their gRPC code.
+func FromStatus(st *status.Status) *Error {
+	e := &Error{Code: codeFromGRPC(st.Code()), Message: st.Message(), Err: st.Err()}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() == errorDomain {
+				e.Code = Code(d.GetReason())
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range d.GetFieldViolations() {
+				e.Fields = append(e.Fields, FieldError{
+					Field:   violation.GetField(),
+					Rule:    violation.GetReason(),
+					Message: violation.GetDescription(),
+				})
+			}
+		}
+	}
+	return e
+}
+
+func codeFromGRPC(c codes.Code) Code {
+	switch c {
+	case codes.OK:
+		return ""
+	case codes.InvalidArgument, codes.OutOfRange:
+		return CodeInvalidArgument
+	case codes.Unauthenticated:
+		return CodeUnauthenticated
+	case codes.PermissionDenied:
+		return CodePermissionDenied
+	case codes.FailedPrecondition:
+		return CodeFailedPrecondition
+	case codes.NotFound:
+		return CodeNotFound
+	case codes.AlreadyExists:
+		return CodeAlreadyExists
+	case codes.Aborted:
+		return CodeConflict
+	case codes.Canceled:
+		return CodeCanceled
+	case codes.DeadlineExceeded:
+		return CodeDeadlineExceeded
+	default:
+		return CodeInternal
+	}
+}
+
+// New returns an error with code and a client-facing message.
+func New(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap is like New but keeps err as the cause.
+func Wrap(err error, code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// NotFoundAs turns pgx.ErrNoRows into a not_found error naming the missing
+// resource, e.g. NotFoundAs(err, "account %d", id). Other errors are
+// returned unchanged.
+func NotFoundAs(err error, format string, args ...any) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Wrap(err, CodeNotFound, "%s not found", fmt.Sprintf(format, args...))
+	}
+	return err
+}
+
+// From classifies err. Errors of this package are returned as is; store and
+// Postgres errors are mapped by kind and SQLSTATE; anything else is internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return Wrap(err, CodeNotFound, "resource not found")
+	case errors.Is(err, context.Canceled):
+		return Wrap(err, CodeCanceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, CodeDeadlineExceeded, "request timed out")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return Wrap(err, CodeAlreadyExists, "resource already exists")
+		case foreignKeyViolation:
+			return Wrap(err, CodeInvalidReference, "referenced resource does not exist")
+		case checkViolation:
+			return Wrap(err, CodeFailedPrecondition, "request violates a data constraint")
+		case serializationFailure, deadlockDetected:
+			return Wrap(err, CodeConflict, "concurrent update, retry the request")
+		case queryCanceled:
+			// raised when statement_timeout expires
+			return Wrap(err, CodeDeadlineExceeded, "request timed out")
+		}
+	}
+
+	return Wrap(err, CodeInternal, "internal server error")
+}
@@ -0,0 +1,149 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestFrom(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+	}{
+		{name: "NoRows", err: pgx.ErrNoRows, code: CodeNotFound, status: http.StatusNotFound},
+		{name: "WrappedNoRows", err: fmt.Errorf("get account: %w", pgx.ErrNoRows), code: CodeNotFound, status: http.StatusNotFound},
+		{name: "UniqueViolation", err: &pgconn.PgError{Code: "23505"}, code: CodeAlreadyExists, status: http.StatusConflict},
+		{name: "ForeignKeyViolation", err: &pgconn.PgError{Code: "23503"}, code: CodeInvalidReference, status: http.StatusUnprocessableEntity},
+		{name: "CheckViolation", err: &pgconn.PgError{Code: "23514"}, code: CodeFailedPrecondition, status: http.StatusBadRequest},
+		{name: "SerializationFailure", err: &pgconn.PgError{Code: "40001"}, code: CodeConflict, status: http.StatusConflict},
+		{name: "Deadlock", err: &pgconn.PgError{Code: "40P01"}, code: CodeConflict, status: http.StatusConflict},
+		{name: "StatementTimeout", err: &pgconn.PgError{Code: "57014"}, code: CodeDeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "OtherPgError", err: &pgconn.PgError{Code: "42P01", Message: "relation does not exist"}, code: CodeInternal, status: http.StatusInternalServerError},
+		{name: "Canceled", err: context.Canceled, code: CodeCanceled, status: 499},
+		{name: "DeadlineExceeded", err: context.DeadlineExceeded, code: CodeDeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "Unknown", err: errors.New("boom"), code: CodeInternal, status: http.StatusInternalServerError},
+		{name: "AppError", err: New(CodeFailedPrecondition, "account is frozen"), code: CodeFailedPrecondition, status: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			appErr := From(tc.err)
+			require.Equal(t, tc.code, appErr.Code)
+			require.Equal(t, tc.status, appErr.HTTPStatus())
+			require.ErrorIs(t, appErr, tc.err)
+		})
+	}
+}
+
+func TestFromDoesNotLeakCause(t *testing.T) {
+	err := &pgconn.PgError{Code: "42P01", Message: `relation "accounts" does not exist`}
+	problem := From(err).Problem("/accounts")
+
+	require.Equal(t, "internal server error", problem.Detail)
+	require.NotContains(t, problem.Detail, "accounts")
+	require.Equal(t, "/problems/internal", problem.Type)
+	require.Equal(t, http.StatusInternalServerError, problem.Status)
+	require.Equal(t, "/accounts", problem.Instance)
+}
+
+func TestNotFoundAs(t *testing.T) {
+	err := NotFoundAs(pgx.ErrNoRows, "account %d", 42)
+	appErr := From(err)
+	require.Equal(t, CodeNotFound, appErr.Code)
+	require.Equal(t, "account 42 not found", appErr.Message)
+
+	other := errors.New("boom")
+	require.Equal(t, other, NotFoundAs(other, "account %d", 42))
+}
+
+func TestInvalid(t *testing.T) {
+	type request struct {
+		Currency string `validate:"required,oneof=USD EUR"`
+		Amount   int64  `validate:"gt=0"`
+	}
+
+	err := validator.New().Struct(request{Amount: -1})
+	appErr := Invalid(err)
+	require.Equal(t, CodeInvalidArgument, appErr.Code)
+	require.Equal(t, []FieldError{
+		{Field: "Currency", Rule: "required", Message: "is required"},
+		{Field: "Amount", Rule: "gt", Message: "must be greater than 0"},
+	}, appErr.Fields)
+
+	appErr = Invalid(errors.New("invalid character"))
+	require.Equal(t, CodeInvalidArgument, appErr.Code)
+	require.Empty(t, appErr.Fields)
+
+	appErr = Invalid(fmt.Errorf("read body: %w", &http.MaxBytesError{Limit: 1024}))
+	require.Equal(t, CodeRequestTooLarge, appErr.Code)
+	require.Equal(t, http.StatusRequestEntityTooLarge, appErr.HTTPStatus())
+	require.Equal(t, "request body exceeds 1024 bytes", appErr.Message)
+}
+
+func TestGRPCStatusRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  *Error
+		code codes.Code
+	}{
+		{name: "InvalidReference", err: New(CodeInvalidReference, "owner does not exist"), code: codes.FailedPrecondition},
+		{name: "Conflict", err: New(CodeConflict, "retry"), code: codes.Aborted},
+		{name: "Fields", err: InvalidField("currency", "oneof", "must be one of USD, EUR"), code: codes.InvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := tc.err.GRPCStatus()
+			require.Equal(t, tc.code, st.Code())
+
+			restored := FromStatus(st)
+			require.Equal(t, tc.err.Code, restored.Code)
+			require.Equal(t, tc.err.Message, restored.Message)
+			require.Equal(t, tc.err.Fields, restored.Fields)
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	type request struct {
+		Owner    string `validate:"min=3"`
+		Password string `validate:"required"`
+		Amount   int64  `validate:"gt=0"`
+		Currency string `validate:"oneof=USD EUR"`
+	}
+
+	appErr := Invalid(validator.New().Struct(request{Owner: "al", Amount: -1, Currency: "XYZ"}))
+	require.Equal(t, "must be at least 3 characters long", appErr.Fields[0].Message)
+
+	localized := Localize(appErr, LangRussian)
+	require.Equal(t, CodeInvalidArgument, localized.Code)
+	require.Equal(t, "запрос не прошёл проверку", localized.Message)
+	require.Equal(t, []FieldError{
+		{Field: "Owner", Rule: "min", Message: "должно содержать не менее 3 символов"},
+		{Field: "Password", Rule: "required", Message: "обязательное поле"},
+		{Field: "Amount", Rule: "gt", Message: "должно быть больше 0"},
+		{Field: "Currency", Rule: "oneof", Message: "должно быть одним из значений: USD, EUR"},
+	}, localized.Fields)
+	require.Equal(t, appErr.Err, localized.Err)
+
+	typeErr := Invalid(&json.UnmarshalTypeError{Field: "amount", Value: "string", Type: reflect.TypeFor[int64]()})
+	require.Equal(t, "должно иметь тип int64", Localize(typeErr, LangRussian).Fields[0].Message)
+
+	custom := InvalidField("currency", "currency_mismatch", "does not match")
+	require.Same(t, custom, Localize(custom, LangRussian))
+	require.Same(t, appErr, Localize(appErr, LangEnglish))
+	require.Same(t, appErr, Localize(appErr, "de"))
+}
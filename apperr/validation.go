@@ -0,0 +1,167 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Languages of validation messages.
+const (
+	LangEnglish = "en"
+	LangRussian = "ru"
+)
+
+// messages are the validation messages of one language. Rule messages are
+// formats of the rule parameter.
+type messages struct {
+	failed string
+	// rules by validator tag
+	rules map[string]string
+	// lengths replace rules for min and max of strings
+	lengths map[string]string
+	typ     string
+	unknown string
+}
+
+var catalog = map[string]messages{
+	LangEnglish: {
+		failed: "request validation failed",
+		rules: map[string]string{
+			"required": "is required",
+			"min":      "must be at least %s",
+			"max":      "must be at most %s",
+			"gt":       "must be greater than %s",
+			"oneof":    "must be one of %s",
+			"url":      "must be a valid URL",
+			"email":    "must be a valid email address",
+			"currency": "must be a supported currency",
+		},
+		lengths: map[string]string{
+			"min": "must be at least %s characters long",
+			"max": "must be at most %s characters long",
+		},
+		typ:     "must be %s",
+		unknown: "failed the %q rule",
+	},
+	LangRussian: {
+		failed: "запрос не прошёл проверку",
+		rules: map[string]string{
+			"required": "обязательное поле",
+			"min":      "должно быть не меньше %s",
+			"max":      "должно быть не больше %s",
+			"gt":       "должно быть больше %s",
+			"oneof":    "должно быть одним из значений: %s",
+			"url":      "должно быть корректным URL",
+			"email":    "должно быть корректным адресом электронной почты",
+			"currency": "должно быть поддерживаемой валютой",
+		},
+		lengths: map[string]string{
+			"min": "должно содержать не менее %s символов",
+			"max": "должно содержать не более %s символов",
+		},
+		typ:     "должно иметь тип %s",
+		unknown: "не прошло проверку %q",
+	},
+}
+
+// SupportsLanguage reports whether validation messages are available in
+// lang, a lowercase ISO 639-1 code.
+func SupportsLanguage(lang string) bool {
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Invalid converts a request binding error into an invalid_argument error
+// with one FieldError per rejected field, in English.
+func Invalid(err error) *Error {
+	return invalid(err, LangEnglish)
+}
+
+// Localize returns e with its validation messages in lang. Errors not made
+// by Invalid from a validation or type error, and unsupported languages,
+// are returned unchanged.
+func Localize(e *Error, lang string) *Error {
+	if e.Code != CodeInvalidArgument || lang == LangEnglish || !SupportsLanguage(lang) {
+		return e
+	}
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(e.Err, &validationErrs) || errors.As(e.Err, &typeErr) {
+		return invalid(e.Err, lang)
+	}
+	return e
+}
+
+func invalid(err error, lang string) *Error {
+	msgs := catalog[lang]
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: msgs.field(fe),
+			})
+		}
+		return &Error{Code: CodeInvalidArgument, Message: msgs.failed, Fields: fields, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Code:    CodeInvalidArgument,
+			Message: msgs.failed,
+			Fields: []FieldError{{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Message: fmt.Sprintf(msgs.typ, typeErr.Type),
+			}},
+			Err: err,
+		}
+	}
+
+	var sizeErr *http.MaxBytesError
+	if errors.As(err, &sizeErr) {
+		return Wrap(err, CodeRequestTooLarge, "request body exceeds %d bytes", sizeErr.Limit)
+	}
+
+	return Wrap(err, CodeInvalidArgument, "malformed request")
+}
+
+// InvalidField returns an invalid_argument error for a single field.
+func InvalidField(field, rule, format string, args ...any) *Error {
+	message := fmt.Sprintf(format, args...)
+	return &Error{
+		Code:    CodeInvalidArgument,
+		Message: "request validation failed",
+		Fields:  []FieldError{{Field: field, Rule: rule, Message: message}},
+	}
+}
+
+func (msgs messages) field(fe validator.FieldError) string {
+	param := fe.Param()
+	if fe.Tag() == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+
+	format, ok := msgs.lengths[fe.Tag()]
+	if !ok || fe.Kind() != reflect.String {
+		format, ok = msgs.rules[fe.Tag()]
+	}
+	if !ok {
+		return fmt.Sprintf(msgs.unknown, fe.Tag())
+	}
+	if !strings.Contains(format, "%") {
+		return format
+	}
+	return fmt.Sprintf(format, param)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/spf13/cobra"
+)
+
+func newAccountCommand(env *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "account",
+		Short: "Manage accounts",
+	}
+
+	var arg db.CreateAccountParams
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Open an account with a zero balance",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !util.IsSupportedCurrency(arg.Currency) {
+				return fmt.Errorf("unsupported currency %q", arg.Currency)
+			}
+
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return audited(ctx, store, cmd, func(ctx context.Context) error {
+				account, err := store.CreateAccountTx(ctx, arg)
+				if err != nil {
+					return err
+				}
+				return printJSON(cmd, account)
+			})
+		},
+	}
+	create.Flags().StringVar(&arg.Owner, "owner", "", "username of the owner")
+	create.Flags().StringVar(&arg.Currency, "currency", "", "account currency, e.g. USD")
+	create.MarkFlagRequired("owner")
+	create.MarkFlagRequired("currency")
+
+	cmd.AddCommand(
+		create,
+		newAccountStatusCommand(env, "freeze", "Freeze an account, blocking its transfers", db.AccountStatusFrozen),
+		newAccountStatusCommand(env, "close", "Close an account for good", db.AccountStatusClosed),
+	)
+	return cmd
+}
+
+func newAccountStatusCommand(env *env, name, short, status string) *cobra.Command {
+	var ifVersion int64
+
+	cmd := &cobra.Command{
+		Use:   name + " ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid account id %q", args[0])
+			}
+
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return audited(ctx, store, cmd, func(ctx context.Context) error {
+				account, err := store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{ID: id, Status: status, IfVersion: ifVersion})
+				if err != nil {
+					return err
+				}
+				return printJSON(cmd, account)
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&ifVersion, "if-version", 0, "fail unless the account is still at this version (any version when 0)")
+	return cmd
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(env *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Validate the configuration and print it with secrets redacted",
+		Long: "Loads app.env, the app.<APP_ENV>.env profile, environment variables and *_FILE\n" +
+			"secrets as serve would, reports invalid settings and prints the result.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := env.config()
+			if err != nil {
+				return err
+			}
+			for _, line := range config.Redacted() {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+// env loads the configuration and connects to the database for commands.
+type env struct {
+	configPath string
+}
+
+func (env *env) config() (util.Config, error) {
+	config, err := util.LoadConfig(env.configPath)
+	if err != nil {
+		return config, fmt.Errorf("failed to load config: %w", err)
+	}
+	return config, nil
+}
+
+// connect opens a pool to the primary database.
+func (env *env) connect(ctx context.Context, config util.Config) (*pgxpool.Pool, error) {
+	return env.connectURL(ctx, config, config.DatabaseURL())
+}
+
+// connectURL opens a pool to databaseURL with the query tracer, the pool
+// tuning and the session timeouts of config.
+func (env *env) connectURL(ctx context.Context, config util.Config, databaseURL string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid db config: %w", err)
+	}
+	tunePool(poolConfig, config)
+	poolConfig.ConnConfig.Tracer = &db.QueryTracer{SlowThreshold: config.SlowQueryThreshold}
+	setTimeoutParam(poolConfig, "statement_timeout", config.DBStatementTimeout)
+	setTimeoutParam(poolConfig, "idle_in_transaction_session_timeout", config.DBIdleInTransactionTimeout)
+
+	connPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to db: %w", err)
+	}
+	return connPool, nil
+}
+
+// store loads the configuration and opens a store; close releases its pool.
+func (env *env) store(ctx context.Context) (store db.Store, close func(), err error) {
+	config, err := env.config()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connPool, err := env.connect(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.NewStore(connPool, db.WithTxRetries(config.TxMaxRetries)), connPool.Close, nil
+}
+
+// tunePool applies the pool settings of config that are set, keeping the
+// pgxpool defaults for the rest.
+func tunePool(poolConfig *pgxpool.Config, config util.Config) {
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = config.DBMinConns
+	}
+	if config.DBMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	}
+	if config.DBMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	}
+	if config.DBHealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = config.DBHealthCheckPeriod
+	}
+}
+
+// setTimeoutParam sets a Postgres timeout parameter of every pool connection.
+// A zero timeout keeps the server default.
+func setTimeoutParam(poolConfig *pgxpool.Config, name string, timeout time.Duration) {
+	if timeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams[name] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+}
+
+// audited runs fn with an audit record of the command attached to ctx. Store
+// transactions write it atomically with their change; otherwise it is
+// written once fn returns, with the HTTP status its error maps to.
+func audited(ctx context.Context, store db.Store, cmd *cobra.Command, fn func(ctx context.Context) error) error {
+	record := &db.AuditRecord{
+		Actor:     "cli:" + operator(),
+		Method:    "CLI",
+		Route:     strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		RequestID: logging.NewRequestID(),
+		ClientIP:  hostname(),
+	}
+
+	err := fn(db.WithAuditRecord(ctx, record))
+	if record.Written() {
+		return err
+	}
+
+	statusCode := 200
+	if err != nil {
+		statusCode = apperr.From(err).HTTPStatus()
+	}
+	if auditErr := store.WriteAuditRecord(context.WithoutCancel(ctx), record, statusCode); auditErr != nil {
+		logging.FromContext(ctx).Error("failed to write audit record", "error", auditErr)
+	}
+	return err
+}
+
+// operator names the OS user running the command.
+func operator() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+func hostname() string {
+	if name, err := os.Hostname(); err == nil {
+		return name
+	}
+	return "localhost"
+}
@@ -0,0 +1,43 @@
+// Command bankctl runs the simple bank server and administers its database.
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// @title       Simple Bank API
+// @version     1.0
+// @description Accounts, transfers and webhooks of the simple bank.
+// @BasePath    /
+//
+// @securityDefinitions.basic  BasicAuth
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	env := &env{}
+
+	root := &cobra.Command{
+		Use:          "bankctl",
+		Short:        "Run and administer the simple bank",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&env.configPath, "config", ".", "directory containing app.env and its profiles")
+
+	root.AddCommand(
+		newServeCommand(env),
+		newMigrateCommand(env),
+		newUserCommand(env),
+		newAccountCommand(env),
+		newTransferCommand(env),
+		newReconcileCommand(env),
+		newSeedCommand(env),
+		newConfigCommand(env),
+	)
+	return root
+}
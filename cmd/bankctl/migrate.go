@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/avfirsov/golang-backend-masterclass/db/migration"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand(env *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or inspect the migrations embedded in this binary",
+	}
+
+	var all bool
+	down := &cobra.Command{
+		Use:   "down [N]",
+		Short: "Roll back the last N migrations (default 1)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps, err := stepsArg(args, 1)
+			if err != nil {
+				return err
+			}
+			return withMigrate(env, cmd.OutOrStdout(), func(m *migrate.Migrate) error {
+				if all {
+					return m.Down()
+				}
+				return m.Steps(-steps)
+			})
+		},
+	}
+	down.Flags().BoolVar(&all, "all", false, "roll back every migration")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up [N]",
+			Short: "Apply all pending migrations, or the next N",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps, err := stepsArg(args, 0)
+				if err != nil {
+					return err
+				}
+
+				if steps == 0 {
+					config, err := env.config()
+					if err != nil {
+						return err
+					}
+					from, to, err := migration.Up(cmd.Context(), config.DatabaseURL(), &migrateLogger{out: cmd.OutOrStdout()})
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "migrated from %d to %d\n", from, to)
+					return nil
+				}
+
+				return withMigrate(env, cmd.OutOrStdout(), func(m *migrate.Migrate) error {
+					return m.Steps(steps)
+				})
+			},
+		},
+		down,
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the applied and the latest embedded migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withMigrate(env, io.Discard, func(m *migrate.Migrate) error {
+					version, dirty, err := m.Version()
+					if errors.Is(err, migrate.ErrNilVersion) {
+						version, err = 0, nil
+					}
+					if err != nil {
+						return err
+					}
+
+					pending := 0
+					for _, v := range migration.Versions() {
+						if v > version {
+							pending++
+						}
+					}
+
+					out := cmd.OutOrStdout()
+					fmt.Fprintf(out, "version: %d\nlatest:  %d\n", version, migration.Latest())
+					switch err := migration.Check(version, dirty); {
+					case err != nil:
+						fmt.Fprintf(out, "status:  %v\n", err)
+					case pending > 0:
+						fmt.Fprintf(out, "status:  %d pending\n", pending)
+					default:
+						fmt.Fprintln(out, "status:  up to date")
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Mark VERSION as applied and clear the dirty flag",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+				return withMigrate(env, cmd.OutOrStdout(), func(m *migrate.Migrate) error {
+					return m.Force(version)
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+// withMigrate runs fn on a migrator of the configured database. Having
+// nothing to migrate is not an error.
+func withMigrate(env *env, out io.Writer, fn func(m *migrate.Migrate) error) error {
+	config, err := env.config()
+	if err != nil {
+		return err
+	}
+
+	m, err := migration.New(config.DatabaseURL())
+	if err != nil {
+		return fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer m.Close()
+	m.Log = &migrateLogger{out: out}
+
+	err = fn(m)
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Fprintln(out, "no change")
+		return nil
+	}
+	return err
+}
+
+func stepsArg(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+	return steps, nil
+}
+
+// migrateLogger prints migrate's progress.
+type migrateLogger struct {
+	out io.Writer
+}
+
+func (logger *migrateLogger) Printf(format string, v ...any) {
+	fmt.Fprintf(logger.out, format, v...)
+}
+
+func (logger *migrateLogger) Verbose() bool {
+	return true
+}
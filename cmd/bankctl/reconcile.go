@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/spf13/cobra"
+)
+
+const pageSize = 100
+
+// errReconcileFailed reports that the ledger does not reconcile; the details
+// have already been printed.
+var errReconcileFailed = errors.New("ledger does not reconcile")
+
+func newReconcileCommand(env *env) *cobra.Command {
+	var accountID int64
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Verify the entry hash chains and that every journal balances",
+		Long: "Recomputes the entry hash chain of every account, reporting the first tampered\n" +
+			"entry, and lists journals whose entries do not sum to zero per currency.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return reconcile(ctx, store, cmd.OutOrStdout(), accountID)
+		},
+	}
+	cmd.Flags().Int64Var(&accountID, "account", 0, "verify a single account (all accounts when 0)")
+	return cmd
+}
+
+func reconcile(ctx context.Context, store db.Store, out io.Writer, accountID int64) error {
+	accountIDs := []int64{accountID}
+	if accountID == 0 {
+		var err error
+		if accountIDs, err = allAccountIDs(ctx, store); err != nil {
+			return err
+		}
+	}
+
+	failed := false
+	for _, id := range accountIDs {
+		result, err := store.VerifyAccountChain(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to verify account %d: %w", id, err)
+		}
+		if !result.Valid {
+			failed = true
+			fmt.Fprintf(out, "account %d: entry %d tampered: %s\n", id, result.TamperedEntryID, result.Reason)
+			continue
+		}
+		fmt.Fprintf(out, "account %d: ok (%d entries)\n", id, result.EntriesChecked)
+	}
+
+	unbalanced, err := store.ListUnbalancedJournals(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check journals: %w", err)
+	}
+	for _, journal := range unbalanced {
+		failed = true
+		fmt.Fprintf(out, "journal %d: %s entries sum to %d\n", journal.JournalID, journal.Currency, journal.Total)
+	}
+	if len(unbalanced) == 0 {
+		fmt.Fprintln(out, "journals: ok")
+	}
+
+	if failed {
+		return errReconcileFailed
+	}
+	return nil
+}
+
+func allAccountIDs(ctx context.Context, store db.Store) ([]int64, error) {
+	var ids []int64
+	for offset := int32(0); ; offset += pageSize {
+		accounts, err := store.ListAccounts(ctx, db.ListAccountsParams{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, account := range accounts {
+			ids = append(ids, account.ID)
+		}
+		if len(accounts) < pageSize {
+			return ids, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReconcile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		chain      db.ChainVerification
+		unbalanced []db.ListUnbalancedJournalsRow
+		output     []string
+		err        error
+	}{
+		{
+			name:   "OK",
+			chain:  db.ChainVerification{AccountID: 7, EntriesChecked: 3, Valid: true},
+			output: []string{"account 7: ok (3 entries)", "journals: ok"},
+		},
+		{
+			name:   "TamperedEntry",
+			chain:  db.ChainVerification{AccountID: 7, EntriesChecked: 2, TamperedEntryID: 12, Reason: "hash does not match entry contents"},
+			output: []string{"account 7: entry 12 tampered: hash does not match entry contents"},
+			err:    errReconcileFailed,
+		},
+		{
+			name:       "UnbalancedJournal",
+			chain:      db.ChainVerification{AccountID: 7, EntriesChecked: 3, Valid: true},
+			unbalanced: []db.ListUnbalancedJournalsRow{{JournalID: 4, Currency: "USD", Total: 10}},
+			output:     []string{"journal 4: USD entries sum to 10"},
+			err:        errReconcileFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().VerifyAccountChain(gomock.Any(), int64(7)).Times(1).Return(tc.chain, nil)
+			store.EXPECT().ListUnbalancedJournals(gomock.Any()).Times(1).Return(tc.unbalanced, nil)
+
+			var out bytes.Buffer
+			err := reconcile(context.Background(), store, &out, 7)
+			require.Equal(t, tc.err, err)
+			for _, line := range tc.output {
+				require.Contains(t, out.String(), line)
+			}
+		})
+	}
+}
+
+func TestReconcileAllAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	page := make([]db.Account, pageSize)
+	for i := range page {
+		page[i].ID = int64(i + 1)
+	}
+	gomock.InOrder(
+		store.EXPECT().ListAccounts(gomock.Any(), db.ListAccountsParams{Limit: pageSize, Offset: 0}).Return(page, nil),
+		store.EXPECT().ListAccounts(gomock.Any(), db.ListAccountsParams{Limit: pageSize, Offset: pageSize}).Return([]db.Account{{ID: pageSize + 1}}, nil),
+	)
+	store.EXPECT().VerifyAccountChain(gomock.Any(), gomock.Any()).Times(pageSize+1).Return(db.ChainVerification{Valid: true}, nil)
+	store.EXPECT().ListUnbalancedJournals(gomock.Any()).Times(1).Return(nil, nil)
+
+	require.NoError(t, reconcile(context.Background(), store, &bytes.Buffer{}, 0))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/go-faker/faker/v4"
+	"github.com/spf13/cobra"
+)
+
+var seedCurrencies = []string{util.USD, util.EUR, util.RUB, util.CAD}
+
+func newSeedCommand(env *env) *cobra.Command {
+	var users, transfers int
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill a development database with random users, accounts and transfers",
+		Long: "Creates users with a funded account in every currency, then makes random\n" +
+			"transfers between them. Meant for development databases only.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(password) < minPasswordLength {
+				return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+			}
+
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return seed(ctx, store, cmd.OutOrStdout(), users, transfers, password)
+		},
+	}
+	cmd.Flags().IntVar(&users, "users", 5, "number of users to create")
+	cmd.Flags().IntVar(&transfers, "transfers", 20, "number of random transfers to make")
+	cmd.Flags().StringVar(&password, "password", "secret", "password of every seeded user")
+	return cmd
+}
+
+func seed(ctx context.Context, store db.Store, out io.Writer, users, transfers int, password string) error {
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[string][]db.Account)
+	for range users {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       faker.Username(),
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner(),
+			Email:          faker.Email(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		for _, currency := range seedCurrencies {
+			account, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+				Owner:    user.Username,
+				Currency: currency,
+				Balance:  util.RandomMoney(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create account: %w", err)
+			}
+			accounts[currency] = append(accounts[currency], account)
+		}
+		fmt.Fprintf(out, "user %s: %d accounts\n", user.Username, len(seedCurrencies))
+	}
+
+	made := 0
+	for range transfers {
+		pool := accounts[seedCurrencies[rand.IntN(len(seedCurrencies))]]
+		if len(pool) < 2 {
+			break
+		}
+
+		i, j := rand.IntN(len(pool)), rand.IntN(len(pool)-1)
+		if j >= i {
+			j++
+		}
+		amount := min(int64(util.RandomInt(1, 10_000)), pool[i].Balance)
+		if amount == 0 {
+			continue
+		}
+
+		result, err := store.TransferTx(ctx, db.TransferTxParams{
+			FromAccountID: pool[i].ID,
+			ToAccountID:   pool[j].ID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to transfer: %w", err)
+		}
+		pool[i], pool[j] = result.FromAccount, result.ToAccount
+		made++
+	}
+	fmt.Fprintf(out, "%d transfers\n", made)
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/avfirsov/golang-backend-masterclass/api"
+	"github.com/avfirsov/golang-backend-masterclass/db/migration"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/events"
+	"github.com/avfirsov/golang-backend-masterclass/gapi"
+	"github.com/avfirsov/golang-backend-masterclass/jobs"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/metrics"
+	"github.com/avfirsov/golang-backend-masterclass/notify"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"github.com/avfirsov/golang-backend-masterclass/tracing"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/avfirsov/golang-backend-masterclass/webhooks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand(env *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the HTTP and gRPC APIs and run the background workers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(env)
+		},
+	}
+}
+
+// serve runs until SIGINT or SIGTERM, then shuts down gracefully.
+func serve(env *env) error {
+	config, err := env.config()
+	if err != nil {
+		return err
+	}
+
+	logger, err := logging.New(os.Stdout, config.LogLevel, config.LogFormat)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter, config.TracingOTLPEndpoint, config.TracingSampleRatio)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	connPool, err := env.connect(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	// closed last, after the servers and workers using it have stopped
+	defer connPool.Close()
+
+	prometheus.MustRegister(metrics.NewPoolCollector(connPool, "primary"))
+
+	replicas, err := connectReplicas(env, config)
+	if err != nil {
+		return err
+	}
+	defer replicas.Close()
+
+	store := db.NewTracedStore(db.NewStore(connPool, db.WithTxRetries(config.TxMaxRetries), db.WithReplicas(replicas)))
+
+	if err := prepareSchema(signals, config, store); err != nil {
+		return err
+	}
+
+	publisher, err := newPublisher(config)
+	if err != nil {
+		return fmt.Errorf("failed to create event publisher: %w", err)
+	}
+
+	publishers := events.MultiPublisher{webhooks.NewPublisher(store)}
+	if publisher != nil {
+		publishers = append(publishers, publisher)
+	}
+
+	routeTimeouts, err := api.ParseRouteTimeouts(config.RouteTimeouts)
+	if err != nil {
+		return fmt.Errorf("invalid route timeouts: %w", err)
+	}
+
+	limiter, err := newRateLimiter(config, store)
+	if err != nil {
+		return fmt.Errorf("failed to create rate limiter: %w", err)
+	}
+
+	httpServer, grpcServer := api.NewServer(store), gapi.NewServer(store)
+	proxies := config.TrustedProxies()
+	if err := errors.Join(httpServer.UseTrustedProxies(proxies), grpcServer.UseTrustedProxies(proxies)); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var workersDone sync.WaitGroup
+
+	relay := events.NewRelay(store, publishers, config.OutboxPollInterval)
+	dispatcher := webhooks.NewDispatcher(store, config.WebhookPollInterval, config.WebhookTimeout, config.WebhookMaxAttempts)
+	jobPool := jobs.NewPool(store, config.JobConcurrency, config.JobPollInterval, config.JobVisibilityTimeout)
+	jobPool.Handle(db.JobVerifyAccountChain, jobs.VerifyAccountChain(store))
+	notifications := notify.NewTransfers(store, notify.NewEmail(notify.LogSender{}, config.MailFrom), notify.NewInApp(store))
+	jobPool.Handle(db.JobNotifyTransfer, notifications.Handle)
+	runs := []func(context.Context){relay.Run, dispatcher.Run, jobPool.Run}
+	if replicas.Len() > 0 {
+		runs = append(runs, func(ctx context.Context) { replicas.Monitor(ctx, config.DBReplicaCheckInterval) })
+	}
+	for _, run := range runs {
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			run(workers)
+		}()
+	}
+
+	var servers []server
+	serverErrs := make(chan error, 2)
+
+	httpServer.AddReadinessCheck("outbox_relay", relay.Check, false)
+	httpServer.AddReadinessCheck("webhook_dispatcher", dispatcher.Check, false)
+	httpServer.AddReadinessCheck("job_pool", jobPool.Check, false)
+	if replicas.Len() > 0 {
+		httpServer.AddReadinessCheck("replicas", replicas.Check, false)
+	}
+	httpServer.UseRateLimiter(limiter)
+	httpServer.UseAdmins(config.Admins())
+	httpServer.UseTimeouts(config.RequestTimeout, routeTimeouts)
+	servers = append(servers, httpServer)
+	slog.Info("starting HTTP server", "address", config.ServerAddress)
+	go func() { serverErrs <- httpServer.Start(config) }()
+
+	if config.GRPCServerAddress != "" {
+		grpcServer.UseRateLimiter(limiter)
+		servers = append(servers, grpcServer)
+		slog.Info("starting gRPC server and gateway", "address", config.GRPCServerAddress)
+		go func() { serverErrs <- grpcServer.Start(config) }()
+	}
+
+	var errs []error
+	select {
+	case <-signals.Done():
+		slog.Info("shutting down")
+	case err := <-serverErrs:
+		slog.Error("server failed, shutting down", "error", err)
+		errs = append(errs, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	// requests first, so that in-flight transfers can still reach the db
+	shutdownErrs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() { shutdownErrs <- server.Shutdown(ctx) }()
+	}
+	for range servers {
+		if err := <-shutdownErrs; err != nil {
+			slog.Error("server shutdown failed", "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	stopWorkers()
+	if !waitTimeout(ctx, &workersDone) {
+		slog.Error("background workers did not stop in time")
+		errs = append(errs, errors.New("background workers did not stop in time"))
+	}
+	if closer, ok := publisher.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
+
+	slog.Info("shutdown complete")
+	return errors.Join(errs...)
+}
+
+// connectReplicas opens a pool to each replica of DB_REPLICA_URLS. Replica
+// pools share the tuning and session timeouts of the primary.
+func connectReplicas(env *env, config util.Config) (*db.ReplicaSet, error) {
+	replicas := db.NewReplicaSet()
+	for i, replicaURL := range config.ReplicaURLs() {
+		pool, err := env.connectURL(context.Background(), config, replicaURL)
+		if err != nil {
+			replicas.Close()
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+
+		name := fmt.Sprintf("replica_%d", i+1)
+		prometheus.MustRegister(metrics.NewPoolCollector(pool, name))
+		replicas.Add(name, pool)
+	}
+	return replicas, nil
+}
+
+// prepareSchema applies pending migrations when MIGRATE_ON_START is set and
+// refuses to serve a dirty database or one migrated by a newer binary.
+func prepareSchema(ctx context.Context, config util.Config, store db.Store) error {
+	if config.MigrateOnStart {
+		from, to, err := migration.Up(ctx, config.DatabaseURL(), slogMigrateLogger{})
+		if err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
+		if from != to {
+			slog.Info("database migrated", "from", from, "to", to)
+		}
+		return nil
+	}
+
+	state, err := store.GetSchemaMigration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version, run bankctl migrate up or set MIGRATE_ON_START: %w", err)
+	}
+	if err := migration.Check(uint(state.Version), state.Dirty); err != nil {
+		return err
+	}
+	if latest := migration.Latest(); uint(state.Version) < latest {
+		slog.Warn("database schema is behind, run bankctl migrate up", "version", state.Version, "latest", latest)
+	}
+	return nil
+}
+
+// slogMigrateLogger logs applied migrations.
+type slogMigrateLogger struct{}
+
+func (slogMigrateLogger) Printf(format string, v ...any) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (slogMigrateLogger) Verbose() bool {
+	return false
+}
+
+// server is implemented by the HTTP and gRPC servers.
+type server interface {
+	Start(config util.Config) error
+	Shutdown(ctx context.Context) error
+}
+
+// waitTimeout waits for wg and reports whether it finished before ctx expired.
+func waitTimeout(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func newRateLimiter(config util.Config, store db.Store) (*ratelimit.Limiter, error) {
+	limits := map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassLogin:     ratelimit.PerMinute(config.RateLimitLoginPerMinute, config.RateLimitLoginBurst),
+		ratelimit.ClassTransfers: ratelimit.PerMinute(config.RateLimitTransfersPerMinute, config.RateLimitTransfersBurst),
+		ratelimit.ClassReads:     ratelimit.PerMinute(config.RateLimitReadsPerMinute, config.RateLimitReadsBurst),
+		ratelimit.ClassWrites:    ratelimit.PerMinute(config.RateLimitWritesPerMinute, config.RateLimitWritesBurst),
+	}
+
+	switch config.RateLimitStore {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return ratelimit.New(ratelimit.NewMemoryStore(), limits), nil
+	case "postgres":
+		return ratelimit.New(ratelimit.NewPostgresStore(store), limits), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", config.RateLimitStore)
+	}
+}
+
+func newPublisher(config util.Config) (events.Publisher, error) {
+	switch config.OutboxPublisher {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return events.NewMemoryPublisher(), nil
+	case "file":
+		return events.NewFilePublisher(config.OutboxFile)
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", config.OutboxPublisher)
+	}
+}
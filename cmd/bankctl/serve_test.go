@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/db/migration"
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPrepareSchema(t *testing.T) {
+	latest := int64(migration.Latest())
+
+	testCases := []struct {
+		name  string
+		state db.SchemaMigration
+		err   error
+	}{
+		{name: "Current", state: db.SchemaMigration{Version: latest}},
+		{name: "Behind", state: db.SchemaMigration{Version: latest - 1}},
+		{name: "Dirty", state: db.SchemaMigration{Version: latest, Dirty: true}, err: migration.ErrDirty},
+		{name: "TooNew", state: db.SchemaMigration{Version: latest + 1}, err: migration.ErrSchemaTooNew},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetSchemaMigration(gomock.Any()).Times(1).Return(tc.state, nil)
+
+			err := prepareSchema(context.Background(), util.Config{}, store)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
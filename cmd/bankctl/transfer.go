@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/spf13/cobra"
+)
+
+func newTransferCommand(env *env) *cobra.Command {
+	var arg db.TransferTxParams
+	var currency string
+
+	cmd := &cobra.Command{
+		Use:   "transfer",
+		Short: "Move money between two active accounts of the same currency",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if arg.Amount <= 0 {
+				return fmt.Errorf("amount must be positive")
+			}
+
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			from, err := accountInCurrency(ctx, store, arg.FromAccountID, currency)
+			if err != nil {
+				return err
+			}
+			if from.Balance < arg.Amount {
+				return fmt.Errorf("account %d balance %d is less than %d", from.ID, from.Balance, arg.Amount)
+			}
+			if _, err := accountInCurrency(ctx, store, arg.ToAccountID, currency); err != nil {
+				return err
+			}
+
+			return audited(ctx, store, cmd, func(ctx context.Context) error {
+				result, err := store.TransferTx(ctx, arg)
+				if err != nil {
+					return err
+				}
+				return printJSON(cmd, result)
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&arg.FromAccountID, "from", 0, "account to debit")
+	cmd.Flags().Int64Var(&arg.ToAccountID, "to", 0, "account to credit")
+	cmd.Flags().Int64Var(&arg.Amount, "amount", 0, "amount in minor units")
+	cmd.Flags().StringVar(&currency, "currency", "", "currency of both accounts")
+	for _, name := range []string{"from", "to", "amount", "currency"} {
+		cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+// accountInCurrency returns the account if it is in currency. Its status is
+// checked by TransferTx, once the account is locked.
+func accountInCurrency(ctx context.Context, store db.Store, id int64, currency string) (db.Account, error) {
+	account, err := store.GetAccount(ctx, id)
+	if err != nil {
+		return account, apperr.NotFoundAs(err, "account %d", id)
+	}
+	if account.Currency != currency {
+		return account, fmt.Errorf("account %d currency %s does not match %s", id, account.Currency, currency)
+	}
+	return account, nil
+}
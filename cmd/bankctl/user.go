@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/spf13/cobra"
+)
+
+const minPasswordLength = 6
+
+func newUserCommand(env *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage users",
+	}
+
+	var arg db.CreateUserParams
+	var password string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := mail.ParseAddress(arg.Email); err != nil {
+				return fmt.Errorf("invalid email %q", arg.Email)
+			}
+			if len(password) < minPasswordLength {
+				return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+			}
+
+			hashedPassword, err := util.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			arg.HashedPassword = hashedPassword
+
+			ctx := cmd.Context()
+			store, closeStore, err := env.store(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return audited(ctx, store, cmd, func(ctx context.Context) error {
+				user, err := store.CreateUser(ctx, arg)
+				if err != nil {
+					return err
+				}
+				db.AuditRecordFrom(ctx).AddTarget("user", user.Username)
+
+				return printJSON(cmd, struct {
+					Username  string    `json:"username"`
+					FullName  string    `json:"full_name"`
+					Email     string    `json:"email"`
+					CreatedAt time.Time `json:"created_at"`
+				}{user.Username, user.FullName, user.Email, user.CreatedAt.Time})
+			})
+		},
+	}
+	create.Flags().StringVar(&arg.Username, "username", "", "username")
+	create.Flags().StringVar(&arg.FullName, "full-name", "", "full name")
+	create.Flags().StringVar(&arg.Email, "email", "", "email address")
+	create.Flags().StringVar(&password, "password", "", "initial password")
+	for _, name := range []string{"username", "full-name", "email", "password"} {
+		create.MarkFlagRequired(name)
+	}
+
+	cmd.AddCommand(create)
+	return cmd
+}
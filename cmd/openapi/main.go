@@ -0,0 +1,53 @@
+// Command openapi converts the Swagger 2.0 document produced by swag into the
+// OpenAPI 3 document served by the API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func main() {
+	in := flag.String("in", "docs/swagger/swagger.json", "swagger 2.0 document generated by swag")
+	out := flag.String("out", "docs/openapi.json", "OpenAPI 3 document to write")
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal("failed to read swagger document: ", err)
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(data, &doc2); err != nil {
+		log.Fatal("failed to parse swagger document: ", err)
+	}
+
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		log.Fatal("failed to convert to OpenAPI 3: ", err)
+	}
+
+	// swag describes time.Time by its fields, but it is marshalled as RFC 3339
+	if schema, ok := doc3.Components.Schemas["time.Time"]; ok {
+		schema.Value = openapi3.NewDateTimeSchema()
+	}
+
+	if err := doc3.Validate(context.Background()); err != nil {
+		log.Fatal("invalid OpenAPI document: ", err)
+	}
+
+	data, err = json.MarshalIndent(doc3, "", "  ")
+	if err != nil {
+		log.Fatal("failed to encode OpenAPI document: ", err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatal("failed to write OpenAPI document: ", err)
+	}
+}
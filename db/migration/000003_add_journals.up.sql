@@ -0,0 +1,60 @@
+CREATE TABLE "journals" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint UNIQUE,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "journals"."transfer_id" IS 'null for journals not produced by a transfer';
+
+ALTER TABLE "journals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+-- entries written before journals existed are grouped into a single legacy journal
+WITH "legacy" AS (
+  INSERT INTO "journals" DEFAULT VALUES RETURNING "id"
+)
+UPDATE "entries" SET "journal_id" = (SELECT "id" FROM "legacy");
+
+ALTER TABLE "entries" ALTER COLUMN "journal_id" SET NOT NULL;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
+
+CREATE INDEX ON "entries" ("journal_id");
+
+-- every journal must sum to zero per currency once its transaction commits
+CREATE FUNCTION "check_journal_balanced"() RETURNS trigger AS $$
+DECLARE
+  journal_ids bigint[];
+  unbalanced record;
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    journal_ids := ARRAY[NEW.journal_id];
+  ELSIF TG_OP = 'DELETE' THEN
+    journal_ids := ARRAY[OLD.journal_id];
+  ELSE
+    journal_ids := ARRAY[NEW.journal_id, OLD.journal_id];
+  END IF;
+
+  SELECT e.journal_id, a.currency, SUM(e.amount) AS total INTO unbalanced
+  FROM entries e
+  JOIN accounts a ON a.id = e.account_id
+  WHERE e.journal_id = ANY (journal_ids)
+  GROUP BY e.journal_id, a.currency
+  HAVING SUM(e.amount) <> 0
+  LIMIT 1;
+
+  IF FOUND THEN
+    RAISE EXCEPTION 'journal % is unbalanced: % entries sum to %',
+      unbalanced.journal_id, unbalanced.currency, unbalanced.total
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "entries_journal_balanced"
+  AFTER INSERT OR UPDATE OR DELETE ON "entries"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION "check_journal_balanced"();
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "entry_chain_cutoff";
+
+DROP INDEX IF EXISTS "entries_account_id_id_idx";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "hash";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "prev_hash";
@@ -0,0 +1,17 @@
+ALTER TABLE "entries" ADD COLUMN "prev_hash" bytea;
+
+ALTER TABLE "entries" ADD COLUMN "hash" bytea;
+
+COMMENT ON COLUMN "entries"."prev_hash" IS 'hash of the previous entry of the same account';
+
+COMMENT ON COLUMN "entries"."hash" IS 'sha256 over the entry contents and prev_hash, null for entries written before chaining';
+
+CREATE INDEX ON "entries" ("account_id", "id");
+
+CREATE TABLE "entry_chain_cutoff" (
+  "last_legacy_entry_id" bigint NOT NULL
+);
+
+INSERT INTO "entry_chain_cutoff" SELECT COALESCE(max("id"), 0) FROM "entries";
+
+COMMENT ON TABLE "entry_chain_cutoff" IS 'single row recording the last entry written before chaining; every later entry must have a hash';
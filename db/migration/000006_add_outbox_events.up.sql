@@ -0,0 +1,18 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "version" int NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox_events" ("id") WHERE "published_at" IS NULL;
+
+CREATE INDEX ON "outbox_events" ("aggregate_type", "aggregate_id");
+
+COMMENT ON COLUMN "outbox_events"."version" IS 'schema version of the payload';
+
+COMMENT ON COLUMN "outbox_events"."published_at" IS 'null until the relay has handed the event to the publisher';
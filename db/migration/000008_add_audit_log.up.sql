@@ -0,0 +1,27 @@
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "route" varchar NOT NULL,
+  "target_ids" varchar[] NOT NULL DEFAULT '{}',
+  "request_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "outcome" varchar NOT NULL,
+  "status_code" int,
+  "request_body" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_log" USING gin ("target_ids");
+
+CREATE INDEX ON "audit_log" ("request_id");
+
+CREATE INDEX ON "audit_log" ("created_at");
+
+COMMENT ON COLUMN "audit_log"."target_ids" IS 'affected resources as kind:id';
+
+COMMENT ON COLUMN "audit_log"."outcome" IS 'committed when written in the business transaction, otherwise success or failure';
+
+COMMENT ON COLUMN "audit_log"."status_code" IS 'null when written before the response was sent';
+
+COMMENT ON COLUMN "audit_log"."request_body" IS 'request body with secrets redacted';
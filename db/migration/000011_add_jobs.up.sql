@@ -0,0 +1,28 @@
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "payload" jsonb NOT NULL DEFAULT '{}',
+  "unique_key" varchar,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "finished_at" timestamptz
+);
+
+CREATE UNIQUE INDEX ON "jobs" ("unique_key") WHERE "status" IN ('pending', 'running');
+
+CREATE INDEX ON "jobs" ("run_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "jobs" ("locked_until") WHERE "status" = 'running';
+
+CREATE INDEX ON "jobs" ("id") WHERE "status" = 'failed';
+
+COMMENT ON COLUMN "jobs"."unique_key" IS 'deduplicates jobs while one is pending or running';
+
+COMMENT ON COLUMN "jobs"."status" IS 'pending, running, succeeded or failed';
+
+COMMENT ON COLUMN "jobs"."locked_until" IS 'visibility timeout of the running attempt';
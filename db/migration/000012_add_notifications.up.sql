@@ -0,0 +1,34 @@
+CREATE TABLE "notifications" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "transfer_id" bigint NOT NULL,
+  "title" varchar NOT NULL,
+  "body" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "read_at" timestamptz
+);
+
+CREATE TABLE "notification_preferences" (
+  "username" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "email" boolean NOT NULL DEFAULT true,
+  "in_app" boolean NOT NULL DEFAULT true,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "event_type")
+);
+
+CREATE UNIQUE INDEX ON "notifications" ("username", "event_type", "transfer_id");
+
+CREATE INDEX ON "notifications" ("username", "id");
+
+COMMENT ON COLUMN "notifications"."event_type" IS 'transfer_sent or transfer_received';
+
+COMMENT ON COLUMN "notification_preferences"."min_amount" IS 'transfers below this amount are not notified';
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
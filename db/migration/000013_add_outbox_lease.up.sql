@@ -0,0 +1,3 @@
+ALTER TABLE "outbox_events" ADD COLUMN "locked_until" timestamptz;
+
+COMMENT ON COLUMN "outbox_events"."locked_until" IS 'the relay that claimed the event publishes it until then, after which another relay may claim it';
@@ -0,0 +1,144 @@
+// Package migration embeds the schema migrations and applies them with
+// golang-migrate.
+package migration
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sync"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID is the advisory lock held while migrations are checked and
+// applied, so that replicas starting together migrate one at a time.
+const lockID = 0x73696d706c6562 // "simpleb"
+
+var (
+	// ErrDirty means a migration failed halfway and the database needs
+	// fixing by hand before migrate force clears the flag.
+	ErrDirty = errors.New("database is dirty")
+	// ErrSchemaTooNew means the database was migrated by a newer binary.
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+)
+
+var latest = sync.OnceValue(func() uint {
+	var version uint
+	for _, v := range Versions() {
+		version = max(version, v)
+	}
+	return version
+})
+
+// Latest returns the newest migration version embedded in the binary.
+func Latest() uint {
+	return latest()
+}
+
+// Versions returns the versions of the embedded up migrations in order.
+func Versions() []uint {
+	names, _ := fs.Glob(files, "*.up.sql")
+
+	versions := make([]uint, 0, len(names))
+	for _, name := range names {
+		var version uint
+		if _, err := fmt.Sscanf(name, "%d_", &version); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// Check reports whether this binary can serve a database at version. A
+// database behind Latest is accepted; it only needs migrating.
+func Check(version uint, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it and run bankctl migrate force %d", ErrDirty, version, version)
+	}
+	if version > Latest() {
+		return fmt.Errorf("%w: version %d, binary has %d", ErrSchemaTooNew, version, Latest())
+	}
+	return nil
+}
+
+// New returns a migrator of the database at databaseURL, a postgresql://
+// URL, reading the embedded migrations.
+func New(databaseURL string) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrateURL, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database url: %w", err)
+	}
+	migrateURL.Scheme = "pgx5"
+
+	return migrate.NewWithSourceInstance("iofs", source, migrateURL.String())
+}
+
+// Up applies pending migrations under an advisory lock and returns the
+// versions before and after. It refuses to touch a dirty database or one
+// newer than the binary.
+func Up(ctx context.Context, databaseURL string, logger migrate.Logger) (from, to uint, err error) {
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return 0, 0, fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+
+	m, err := New(databaseURL)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer m.Close()
+	m.Log = logger
+
+	from, err = version(m)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { m.GracefulStop <- true })
+	defer stop()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return from, 0, err
+	}
+	// a graceful stop ends Up early without an error
+	if err := ctx.Err(); err != nil {
+		return from, 0, err
+	}
+
+	to, err = version(m)
+	return from, to, err
+}
+
+// version returns the applied version, 0 on a database never migrated, and
+// fails when Check does.
+func version(m *migrate.Migrate) (uint, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version, Check(version, dirty)
+}
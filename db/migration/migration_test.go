@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	versions := Versions()
+	require.NotEmpty(t, versions)
+	for i, version := range versions {
+		require.Equal(t, uint(i+1), version, "versions must be contiguous")
+	}
+	require.Equal(t, versions[len(versions)-1], Latest())
+
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+	for _, up := range ups {
+		_, err := fs.Stat(files, strings.TrimSuffix(up, ".up.sql")+".down.sql")
+		require.NoError(t, err, "%s has no down migration", up)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, Check(Latest(), false))
+	require.NoError(t, Check(Latest()-1, false), "a database behind only needs migrating")
+	require.NoError(t, Check(0, false))
+	require.ErrorIs(t, Check(Latest(), true), ErrDirty)
+	require.ErrorIs(t, Check(Latest()+1, false), ErrSchemaTooNew)
+}
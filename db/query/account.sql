@@ -24,17 +24,23 @@ OFFSET $2;
 
 -- name: UpdateAccount :one
 UPDATE accounts
-set balance = $2
+set balance = $2, version = version + 1
 WHERE id = $1
 RETURNING *;
 
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount), version = version + 1
 WHERE id = sqlc.arg(account_id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2, version = version + 1
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,2 @@
+-- name: NotifyAccountEvent :exec
+SELECT pg_notify('account_events', sqlc.arg(payload)::text);
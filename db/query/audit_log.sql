@@ -0,0 +1,14 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+    actor, method, route, target_ids, request_id, client_ip, outcome, status_code, request_body
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: ListAuditLogByTarget :many
+SELECT * FROM audit_log
+WHERE sqlc.arg(target)::varchar = ANY(target_ids)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
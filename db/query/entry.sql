@@ -1,6 +1,6 @@
 -- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
+INSERT INTO entries (account_id, amount, journal_id, created_at, prev_hash, hash)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 
@@ -24,6 +24,36 @@ WHERE account_id = $1
 ORDER BY created_at DESC;
 
 
+-- name: GetEntryChainCutoff :one
+-- Returns the id of the last entry written before chaining.
+SELECT last_legacy_entry_id
+FROM entry_chain_cutoff
+LIMIT 1;
+
+
+-- name: GetLastAccountEntry :one
+SELECT *
+FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+
+-- name: ListEntriesByAccountAfter :many
+SELECT *
+FROM entries
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListEntriesByJournal :many
+SELECT *
+FROM entries
+WHERE journal_id = $1
+ORDER BY id;
+
+
 -- name: UpdateEntry :one
 UPDATE entries
 set amount = $2
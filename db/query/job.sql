@@ -0,0 +1,60 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (
+    kind, payload, unique_key, max_attempts, run_at
+) VALUES (
+    $1, $2, $3, $4, COALESCE(sqlc.narg(run_at), now())
+)
+ON CONFLICT (unique_key) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimJobs :many
+-- Claims due jobs of the given kinds, and running ones whose visibility
+-- timeout expired, for their next attempt.
+UPDATE jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until)
+WHERE id IN (
+    SELECT j.id FROM jobs j
+    WHERE j.kind = ANY(sqlc.arg(kinds)::varchar[])
+      AND ((j.status = 'pending' AND j.run_at <= now())
+        OR (j.status = 'running' AND j.locked_until <= now()))
+    ORDER BY j.run_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+UPDATE jobs
+SET status = 'succeeded',
+    locked_until = NULL,
+    finished_at = now()
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET status = 'pending',
+    run_at = $3,
+    locked_until = NULL,
+    last_error = $4
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+
+-- name: FailJob :execrows
+UPDATE jobs
+SET status = 'failed',
+    locked_until = NULL,
+    last_error = $3,
+    finished_at = now()
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+
+-- name: ListJobsByStatus :many
+SELECT * FROM jobs
+WHERE status = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
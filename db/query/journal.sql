@@ -0,0 +1,22 @@
+-- name: CreateJournal :one
+INSERT INTO journals (transfer_id)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetJournal :one
+SELECT * FROM journals
+WHERE id = $1 LIMIT 1;
+
+-- name: GetJournalByTransfer :one
+SELECT * FROM journals
+WHERE transfer_id = $1 LIMIT 1;
+
+-- name: ListUnbalancedJournals :many
+-- Journals whose entries do not sum to zero per currency. The deferred
+-- trigger rejects them, so any row means the ledger was edited around it.
+SELECT e.journal_id, a.currency, SUM(e.amount)::bigint AS total
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+GROUP BY e.journal_id, a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY e.journal_id;
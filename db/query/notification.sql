@@ -0,0 +1,44 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+    username, event_type, transfer_id, title, body
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (username, event_type, transfer_id) DO NOTHING
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE username = sqlc.arg(username)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, now())
+WHERE id = $1 AND username = $2
+RETURNING *;
+
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preferences
+WHERE username = $1 AND event_type = $2 LIMIT 1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE username = $1
+ORDER BY event_type;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+    username, event_type, email, in_app, min_amount
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (username, event_type) DO UPDATE SET
+    email = EXCLUDED.email,
+    in_app = EXCLUDED.in_app,
+    min_amount = EXCLUDED.min_amount,
+    updated_at = now()
+RETURNING *;
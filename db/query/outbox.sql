@@ -0,0 +1,36 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+    aggregate_type, aggregate_id, event_type, version, payload
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+-- Leases the oldest unpublished events that no other relay holds.
+WITH claimed AS (
+    UPDATE outbox_events
+    SET locked_until = sqlc.arg(locked_until)
+    WHERE id IN (
+        SELECT e.id FROM outbox_events e
+        WHERE e.published_at IS NULL
+          AND (e.locked_until IS NULL OR e.locked_until <= now())
+        ORDER BY e.id
+        LIMIT sqlc.arg('limit')
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *
+)
+SELECT * FROM claimed
+ORDER BY id;
+
+-- name: ReleaseOutboxEvents :exec
+UPDATE outbox_events
+SET locked_until = NULL
+WHERE id = ANY(sqlc.arg(ids)::bigint[]) AND published_at IS NULL;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now(),
+    locked_until = NULL
+WHERE id = $1;
@@ -0,0 +1,63 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+    owner, url, event_type, secret
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: EnqueueWebhookDeliveries :many
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+SELECT s.id, sqlc.arg(event_id), s.event_type, sqlc.arg(payload)
+FROM webhook_subscriptions s
+WHERE s.event_type = sqlc.arg(event_type)
+  AND s.owner IN (
+    SELECT a.owner FROM accounts a
+    WHERE a.id = ANY(sqlc.arg(account_ids)::bigint[])
+  )
+ON CONFLICT (subscription_id, event_id) DO NOTHING
+RETURNING *;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT d.id FROM webhook_deliveries d
+    WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+    ORDER BY d.next_attempt_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ExtendWebhookDeliveryLease :one
+-- Renews the lease of a claimed delivery, provided it still holds the lease
+-- it was claimed with.
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id = sqlc.arg(id)
+  AND status = 'pending'
+  AND next_attempt_at = sqlc.arg(leased_until)
+RETURNING *;
+
+-- name: RecordWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    response_status = $4,
+    last_error = $5,
+    delivered_at = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
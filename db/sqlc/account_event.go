@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+)
+
+const accountEventsChannel = "account_events"
+
+// AccountEvent is broadcast through LISTEN/NOTIFY whenever an entry is posted
+// to an account.
+type AccountEvent struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+	Entry     Entry `json:"entry"`
+}
+
+// publishAccountEvent queues a notification that postgres delivers to
+// listeners only when the surrounding transaction commits.
+func publishAccountEvent(ctx context.Context, q *Queries, account Account, entry Entry) error {
+	payload, err := json.Marshal(AccountEvent{
+		AccountID: account.ID,
+		Balance:   account.Balance,
+		Entry:     entry,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.NotifyAccountEvent(ctx, string(payload))
+}
+
+// ListenAccountEvents calls handle for every committed account event until ctx
+// is cancelled or the connection fails. It holds a dedicated connection that
+// is taken out of the pool for the lifetime of the call.
+func (store *SQLStore) ListenAccountEvents(ctx context.Context, handle func(AccountEvent)) error {
+	poolConn, err := store.connPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+accountEventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event AccountEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			logging.FromContext(ctx).Warn("invalid account event", "error", err)
+			continue
+		}
+		handle(event)
+	}
+}
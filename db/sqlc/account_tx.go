@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+)
+
+// Account statuses.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// CreateAccountTx creates an account and records an AccountCreated event.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, TxOptions{Name: "create_account"}, func(q *Queries) error {
+		var err error
+
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if record := AuditRecordFrom(ctx); record != nil {
+			record.AddTarget(AggregateAccount, account.ID)
+		}
+
+		_, err = addOutboxEvent(ctx, q, AggregateAccount, account.ID, EventAccountCreated, 1, AccountCreatedV1{
+			AccountID: account.ID,
+			Owner:     account.Owner,
+			Currency:  account.Currency,
+			Balance:   account.Balance,
+			CreatedAt: account.CreatedAt.Time,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+type UpdateAccountStatusTxParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	// IfVersion, unless zero, is the version the caller last saw. The update
+	// fails with precondition_failed if the account has changed since.
+	IfVersion int64 `json:"if_version"`
+}
+
+// UpdateAccountStatusTx changes the status of an account and records the
+// matching status event. Closing an account also enqueues a final verification
+// of its hash chain. Setting the current status again is a no-op.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error) {
+	var account Account
+
+	eventType, err := accountStatusEvent(arg.Status)
+	if err != nil {
+		return account, err
+	}
+
+	err = store.execTx(ctx, TxOptions{Name: "update_account_status"}, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if record := AuditRecordFrom(ctx); record != nil {
+			record.AddTarget(AggregateAccount, current.ID)
+		}
+
+		if arg.IfVersion != 0 && current.Version != arg.IfVersion {
+			return apperr.New(apperr.CodePreconditionFailed, "account %d has changed, its version is now %d", current.ID, current.Version)
+		}
+
+		if current.Status == arg.Status {
+			account = current
+			return nil
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{ID: arg.ID, Status: arg.Status})
+		if err != nil {
+			return err
+		}
+
+		_, err = addOutboxEvent(ctx, q, AggregateAccount, account.ID, eventType, 1, AccountStatusChangedV1{
+			AccountID:      account.ID,
+			PreviousStatus: current.Status,
+			Status:         account.Status,
+		})
+		if err != nil || account.Status != AccountStatusClosed {
+			return err
+		}
+
+		return addJob(ctx, q, JobVerifyAccountChain, accountChainJobKey(account.ID), VerifyAccountChainJobV1{AccountID: account.ID})
+	})
+
+	return account, err
+}
+
+func accountStatusEvent(status string) (string, error) {
+	switch status {
+	case AccountStatusActive:
+		return EventAccountActivated, nil
+	case AccountStatusFrozen:
+		return EventAccountFrozen, nil
+	case AccountStatusClosed:
+		return EventAccountClosed, nil
+	default:
+		return "", fmt.Errorf("unknown account status %q", status)
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Audit outcomes.
+const (
+	AuditOutcomeCommitted = "committed"
+	AuditOutcomeSuccess   = "success"
+	AuditOutcomeFailure   = "failure"
+)
+
+// AuditRecord describes a state-changing request. When one is attached to the
+// context, execTx writes it in the same transaction as the business change.
+type AuditRecord struct {
+	Actor       string
+	Method      string
+	Route       string
+	RequestID   string
+	ClientIP    string
+	RequestBody []byte
+
+	mu      sync.Mutex
+	targets []string
+	written bool
+}
+
+type auditRecordKey struct{}
+
+// WithAuditRecord returns a copy of ctx carrying record.
+func WithAuditRecord(ctx context.Context, record *AuditRecord) context.Context {
+	return context.WithValue(ctx, auditRecordKey{}, record)
+}
+
+// AuditRecordFrom returns the record attached to ctx, or nil.
+func AuditRecordFrom(ctx context.Context) *AuditRecord {
+	record, _ := ctx.Value(auditRecordKey{}).(*AuditRecord)
+	return record
+}
+
+// AddTarget records an affected resource, e.g. AddTarget("account", 42).
+func (record *AuditRecord) AddTarget(kind string, id any) {
+	target := fmt.Sprintf("%s:%v", kind, id)
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	for _, t := range record.targets {
+		if t == target {
+			return
+		}
+	}
+	record.targets = append(record.targets, target)
+}
+
+// Targets returns the affected resources recorded so far.
+func (record *AuditRecord) Targets() []string {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	return append([]string{}, record.targets...)
+}
+
+// checkpoint returns a marker of the targets recorded so far, for restore.
+func (record *AuditRecord) checkpoint() int {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	return len(record.targets)
+}
+
+// restore drops the targets added since checkpoint returned n.
+func (record *AuditRecord) restore(n int) {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	record.targets = record.targets[:n]
+}
+
+// Written reports whether the record has already been persisted.
+func (record *AuditRecord) Written() bool {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	return record.written
+}
+
+// MarkWritten flags the record as persisted so it is not written again.
+func (record *AuditRecord) MarkWritten() {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	record.written = true
+}
+
+// WriteAuditRecord persists the record outside of any business transaction
+// with the final response status.
+func (store *SQLStore) WriteAuditRecord(ctx context.Context, record *AuditRecord, statusCode int) error {
+	outcome := AuditOutcomeSuccess
+	if statusCode >= 400 {
+		outcome = AuditOutcomeFailure
+	}
+
+	_, err := store.CreateAuditLog(ctx, record.params(outcome, pgtype.Int4{Int32: int32(statusCode), Valid: true}))
+	if err != nil {
+		return err
+	}
+	record.MarkWritten()
+	return nil
+}
+
+// writeAuditInTx persists the record attached to ctx, if any, as part of the
+// transaction about to commit.
+func writeAuditInTx(ctx context.Context, q *Queries) error {
+	record := AuditRecordFrom(ctx)
+	if record == nil || record.Written() {
+		return nil
+	}
+
+	_, err := q.CreateAuditLog(ctx, record.params(AuditOutcomeCommitted, pgtype.Int4{}))
+	return err
+}
+
+func (record *AuditRecord) params(outcome string, statusCode pgtype.Int4) CreateAuditLogParams {
+	return CreateAuditLogParams{
+		Actor:       record.Actor,
+		Method:      record.Method,
+		Route:       record.Route,
+		TargetIds:   record.Targets(),
+		RequestID:   record.RequestID,
+		ClientIp:    record.ClientIP,
+		Outcome:     outcome,
+		StatusCode:  statusCode,
+		RequestBody: record.RequestBody,
+	}
+}
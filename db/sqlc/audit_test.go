@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRecordWrittenInTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	record := &AuditRecord{
+		Actor:       "anonymous",
+		Method:      "POST",
+		Route:       "/accounts",
+		RequestID:   util.RandomOwner(),
+		ClientIP:    "127.0.0.1",
+		RequestBody: []byte(`{"currency":"USD"}`),
+	}
+	ctx := WithAuditRecord(context.Background(), record)
+
+	account, err := store.CreateAccountTx(ctx, CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+	require.True(t, record.Written())
+
+	logs, err := store.ListAuditLogByTarget(context.Background(), ListAuditLogByTargetParams{
+		Target: fmt.Sprintf("%s:%d", AggregateAccount, account.ID),
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, AuditOutcomeCommitted, logs[0].Outcome)
+	require.Equal(t, record.RequestID, logs[0].RequestID)
+	require.False(t, logs[0].StatusCode.Valid)
+}
+
+func TestAuditRecordRolledBack(t *testing.T) {
+	store := NewStore(testPool)
+
+	record := &AuditRecord{Actor: "anonymous", Method: "POST", Route: "/transfers", RequestID: util.RandomOwner()}
+	ctx := WithAuditRecord(context.Background(), record)
+
+	_, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: -1, ToAccountID: -2, Amount: 10})
+	require.Error(t, err)
+	require.False(t, record.Written())
+
+	require.NoError(t, store.WriteAuditRecord(context.Background(), record, 500))
+	require.True(t, record.Written())
+}
+
+func TestAuditTargetsRestoredOnRetry(t *testing.T) {
+	store := NewStore(testPool, WithTxRetries(1)).(*SQLStore)
+	conflict := &pgconn.PgError{Code: serializationFailure}
+	target := util.RandomOwner()
+
+	record := &AuditRecord{Actor: "anonymous", Method: "POST", Route: "/transfers", RequestID: util.RandomOwner()}
+	record.AddTarget("request", target)
+	ctx := WithAuditRecord(context.Background(), record)
+
+	attempts := 0
+	err := store.execTx(ctx, TxOptions{Name: "test"}, func(q *Queries) error {
+		attempts++
+		record.AddTarget("attempt", fmt.Sprintf("%s-%d", target, attempts))
+		if attempts == 1 {
+			return conflict
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, []string{"request:" + target, "attempt:" + target + "-2"}, record.Targets())
+
+	logs, err := store.ListAuditLogByTarget(context.Background(), ListAuditLogByTargetParams{
+		Target: "attempt:" + target + "-1",
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, logs, "the rolled back attempt's target is not audited")
+
+	logs, err = store.ListAuditLogByTarget(context.Background(), ListAuditLogByTargetParams{
+		Target: "request:" + target,
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, record.Targets(), logs[0].TargetIds)
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EntryHash returns the SHA-256 over an entry's contents and the hash of the
+// previous entry of the same account.
+func EntryHash(accountID, journalID, amount int64, createdAt time.Time, prevHash []byte) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%d|%d|%d|%x", accountID, journalID, amount, createdAt.UnixMicro(), prevHash)
+	return h.Sum(nil)
+}
+
+// createChainedEntry appends an entry to the account's hash chain. The caller
+// must hold a lock on the account so that no other entry is chained concurrently.
+func createChainedEntry(ctx context.Context, q *Queries, journalID, accountID, amount int64) (Entry, error) {
+	var prevHash []byte
+	last, err := q.GetLastAccountEntry(ctx, accountID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Entry{}, err
+	}
+	if err == nil {
+		prevHash = last.Hash
+	}
+
+	// postgres stores timestamps with microsecond precision
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	return q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: accountID,
+		Amount:    amount,
+		JournalID: journalID,
+		CreatedAt: pgtype.Timestamptz{Time: createdAt, Valid: true},
+		PrevHash:  prevHash,
+		Hash:      EntryHash(accountID, journalID, amount, createdAt, prevHash),
+	})
+}
+
+type ChainVerification struct {
+	AccountID       int64  `json:"account_id"`
+	EntriesChecked  int    `json:"entries_checked"`
+	Valid           bool   `json:"valid"`
+	TamperedEntryID int64  `json:"tampered_entry_id,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// chainPageSize is how many entries VerifyAccountChain loads at a time.
+const chainPageSize = 1000
+
+// VerifyAccountChain recomputes the hash chain of an account and reports the
+// first entry that does not match. Entries are read a page at a time.
+func (store *SQLStore) VerifyAccountChain(ctx context.Context, accountID int64) (ChainVerification, error) {
+	lastLegacyID, err := store.GetEntryChainCutoff(ctx)
+	if err != nil {
+		return ChainVerification{}, err
+	}
+
+	verifier := newChainVerifier(accountID, lastLegacyID)
+	var afterID int64
+	for {
+		entries, err := store.ListEntriesByAccountAfter(ctx, ListEntriesByAccountAfterParams{
+			AccountID: accountID,
+			AfterID:   afterID,
+			Limit:     chainPageSize,
+		})
+		if err != nil {
+			return ChainVerification{}, err
+		}
+
+		if !verifier.verify(entries) || len(entries) < chainPageSize {
+			return verifier.result, nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
+
+// VerifyChain checks entries of one account ordered by id. Only entries up to
+// lastLegacyID, written before chaining, may lack a hash.
+func VerifyChain(accountID, lastLegacyID int64, entries []Entry) ChainVerification {
+	verifier := newChainVerifier(accountID, lastLegacyID)
+	verifier.verify(entries)
+	return verifier.result
+}
+
+// chainVerifier checks the entries of one account across pages, carrying the
+// hash of the last entry from one page to the next.
+type chainVerifier struct {
+	result       ChainVerification
+	lastLegacyID int64
+	prevHash     []byte
+}
+
+func newChainVerifier(accountID, lastLegacyID int64) *chainVerifier {
+	return &chainVerifier{
+		result:       ChainVerification{AccountID: accountID, Valid: true},
+		lastLegacyID: lastLegacyID,
+	}
+}
+
+// verify checks the next entries of the chain and reports whether it is still
+// valid.
+func (verifier *chainVerifier) verify(entries []Entry) bool {
+	for _, entry := range entries {
+		verifier.result.EntriesChecked++
+
+		reason := ""
+		switch {
+		case entry.Hash == nil && entry.ID <= verifier.lastLegacyID:
+			continue
+		case entry.Hash == nil:
+			reason = "hash is missing"
+		case !bytes.Equal(entry.PrevHash, verifier.prevHash):
+			reason = "prev_hash does not match the previous entry"
+		case !bytes.Equal(entry.Hash, EntryHash(entry.AccountID, entry.JournalID, entry.Amount, entry.CreatedAt.Time, entry.PrevHash)):
+			reason = "hash does not match entry contents"
+		}
+
+		if reason != "" {
+			verifier.result.Valid = false
+			verifier.result.TamperedEntryID = entry.ID
+			verifier.result.Reason = reason
+			return false
+		}
+
+		verifier.prevHash = entry.Hash
+	}
+
+	return true
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func buildChain(accountID int64, amounts ...int64) []Entry {
+	var entries []Entry
+	var prevHash []byte
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	for i, amount := range amounts {
+		hash := EntryHash(accountID, int64(i+1), amount, createdAt, prevHash)
+		entries = append(entries, Entry{
+			ID:        int64(i + 1),
+			AccountID: accountID,
+			Amount:    amount,
+			CreatedAt: pgtype.Timestamptz{Time: createdAt, Valid: true},
+			JournalID: int64(i + 1),
+			PrevHash:  prevHash,
+			Hash:      hash,
+		})
+		prevHash = hash
+	}
+	return entries
+}
+
+func TestVerifyChain(t *testing.T) {
+	legacy := Entry{ID: 1, AccountID: 1, Amount: 5}
+
+	testCases := []struct {
+		name         string
+		lastLegacyID int64
+		entries      func() []Entry
+		tamperedID   int64
+	}{
+		{
+			name:    "Valid",
+			entries: func() []Entry { return buildChain(1, 10, -5, 7) },
+		},
+		{
+			name:         "LegacyPrefix",
+			lastLegacyID: 1,
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5)
+				for i := range chain {
+					chain[i].ID++
+				}
+				return append([]Entry{legacy}, chain...)
+			},
+		},
+		{
+			name: "AmountChanged",
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5, 7)
+				chain[1].Amount = 500
+				return chain
+			},
+			tamperedID: 2,
+		},
+		{
+			name: "EntryDeleted",
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5, 7)
+				return append(chain[:1], chain[2:]...)
+			},
+			tamperedID: 3,
+		},
+		{
+			name: "HashRemoved",
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5, 7)
+				chain[2].Hash = nil
+				return chain
+			},
+			tamperedID: 3,
+		},
+		{
+			name: "FirstHashRemoved",
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5, 7)
+				chain[0].Hash = nil
+				return chain
+			},
+			tamperedID: 1,
+		},
+		{
+			name: "LegacyEntryAfterCutoff",
+			entries: func() []Entry {
+				chain := buildChain(1, 10, -5)
+				for i := range chain {
+					chain[i].ID++
+				}
+				return append([]Entry{legacy}, chain...)
+			},
+			tamperedID: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := tc.entries()
+			result := VerifyChain(1, tc.lastLegacyID, entries)
+
+			require.Equal(t, tc.tamperedID == 0, result.Valid)
+			require.Equal(t, tc.tamperedID, result.TamperedEntryID)
+			if result.Valid {
+				require.Equal(t, len(entries), result.EntriesChecked)
+				require.Empty(t, result.Reason)
+			} else {
+				require.NotEmpty(t, result.Reason)
+			}
+		})
+	}
+}
+
+func TestChainVerifierPages(t *testing.T) {
+	chain := buildChain(1, 10, -5, 7, 3)
+
+	verifier := newChainVerifier(1, 0)
+	require.True(t, verifier.verify(chain[:2]))
+	require.True(t, verifier.verify(chain[2:]))
+	require.Equal(t, ChainVerification{AccountID: 1, EntriesChecked: 4, Valid: true}, verifier.result)
+
+	// the first entry of a page must chain onto the last one of the previous page
+	tampered := buildChain(1, 10, -5, 7, 3)
+	tampered[2].PrevHash = nil
+	tampered[2].Hash = EntryHash(1, tampered[2].JournalID, tampered[2].Amount, tampered[2].CreatedAt.Time, nil)
+
+	verifier = newChainVerifier(1, 0)
+	require.True(t, verifier.verify(tampered[:2]))
+	require.False(t, verifier.verify(tampered[2:]))
+	require.Equal(t, int64(3), verifier.result.TamperedEntryID)
+}
+
+func TestVerifyAccountChain(t *testing.T) {
+	store := NewStore(testPool)
+	account1 := CreateRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	result, err := store.VerifyAccountChain(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+	require.Equal(t, 1, result.EntriesChecked)
+}
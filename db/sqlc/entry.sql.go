@@ -7,27 +7,43 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
-RETURNING id, account_id, amount, created_at
+INSERT INTO entries (account_id, amount, journal_id, created_at, prev_hash, hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, account_id, amount, created_at, journal_id, prev_hash, hash
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID int64              `json:"account_id"`
+	Amount    int64              `json:"amount"`
+	JournalID int64              `json:"journal_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	PrevHash  []byte             `json:"prev_hash"`
+	Hash      []byte             `json:"hash"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.JournalID,
+		arg.CreatedAt,
+		arg.PrevHash,
+		arg.Hash,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
@@ -44,7 +60,7 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
 FROM entries
 WHERE id = $1
 LIMIT 1
@@ -58,12 +74,52 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getEntryChainCutoff = `-- name: GetEntryChainCutoff :one
+SELECT last_legacy_entry_id
+FROM entry_chain_cutoff
+LIMIT 1
+`
+
+// Returns the id of the last entry written before chaining.
+func (q *Queries) GetEntryChainCutoff(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, getEntryChainCutoff)
+	var last_legacy_entry_id int64
+	err := row.Scan(&last_legacy_entry_id)
+	return last_legacy_entry_id, err
+}
+
+const getLastAccountEntry = `-- name: GetLastAccountEntry :one
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
+FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAccountEntry(ctx context.Context, accountID int64) (Entry, error) {
+	row := q.db.QueryRow(ctx, getLastAccountEntry, accountID)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
 FROM entries
 ORDER BY id
 LIMIT $1 OFFSET $2
@@ -88,6 +144,9 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -100,7 +159,7 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 }
 
 const listEntriesByAccount = `-- name: ListEntriesByAccount :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
 FROM entries
 WHERE account_id = $1
 ORDER BY created_at DESC
@@ -120,6 +179,86 @@ func (q *Queries) ListEntriesByAccount(ctx context.Context, accountID int64) ([]
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesByAccountAfter = `-- name: ListEntriesByAccountAfter :many
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
+FROM entries
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListEntriesByAccountAfterParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	Limit     int32 `json:"limit"`
+}
+
+func (q *Queries) ListEntriesByAccountAfter(ctx context.Context, arg ListEntriesByAccountAfterParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listEntriesByAccountAfter, arg.AccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesByJournal = `-- name: ListEntriesByJournal :many
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash
+FROM entries
+WHERE journal_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListEntriesByJournal(ctx context.Context, journalID int64) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listEntriesByJournal, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -135,7 +274,7 @@ const updateEntry = `-- name: UpdateEntry :one
 UPDATE entries
 set amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, journal_id, prev_hash, hash
 `
 
 type UpdateEntryParams struct {
@@ -151,6 +290,9 @@ func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
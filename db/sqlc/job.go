@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Job statuses.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// Job kinds enqueued by the store.
+const (
+	JobVerifyAccountChain = "verify_account_chain"
+	JobNotifyTransfer     = "notify_transfer"
+)
+
+// DefaultJobMaxAttempts is how many times a job is attempted unless its
+// enqueuer asks otherwise.
+const DefaultJobMaxAttempts = 10
+
+// VerifyAccountChainJobV1 is the version 1 payload of JobVerifyAccountChain.
+type VerifyAccountChainJobV1 struct {
+	AccountID int64 `json:"account_id"`
+}
+
+// NotifyTransferJobV1 is the version 1 payload of JobNotifyTransfer. It
+// notifies the owner of one side of a transfer.
+type NotifyTransferJobV1 struct {
+	TransferID int64 `json:"transfer_id"`
+	AccountID  int64 `json:"account_id"`
+	// NotificationTransferSent or NotificationTransferReceived
+	EventType string `json:"event_type"`
+}
+
+// addJob adds a job within the caller's transaction, so workers only see
+// it once the business change commits. A job already pending or running under
+// the same unique key stands in for the new one.
+func addJob(ctx context.Context, q *Queries, kind, uniqueKey string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.EnqueueJob(ctx, EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		UniqueKey:   pgtype.Text{String: uniqueKey, Valid: uniqueKey != ""},
+		MaxAttempts: DefaultJobMaxAttempts,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// accountChainJobKey deduplicates verification jobs of an account.
+func accountChainJobKey(accountID int64) string {
+	return fmt.Sprintf("%s:%d", JobVerifyAccountChain, accountID)
+}
+
+// transferNotificationJobKey deduplicates notification jobs of a transfer side.
+func transferNotificationJobKey(transferID int64, eventType string) string {
+	return fmt.Sprintf("%s:%d:%s", JobNotifyTransfer, transferID, eventType)
+}
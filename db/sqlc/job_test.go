@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func enqueueRandomJob(t *testing.T, kind, uniqueKey string) Job {
+	job, err := testQueries.EnqueueJob(context.Background(), EnqueueJobParams{
+		Kind:        kind,
+		Payload:     json.RawMessage(`{"n":1}`),
+		UniqueKey:   pgtype.Text{String: uniqueKey, Valid: uniqueKey != ""},
+		MaxAttempts: 3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Zero(t, job.Attempts)
+	require.WithinDuration(t, time.Now(), job.RunAt.Time, time.Minute)
+	return job
+}
+
+func claimTestJobs(t *testing.T, kind string) []Job {
+	jobs, err := testQueries.ClaimJobs(context.Background(), ClaimJobsParams{
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+		Kinds:       []string{kind},
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	return jobs
+}
+
+func TestEnqueueJobUniqueKey(t *testing.T) {
+	kind := "test_" + util.RandomOwner()
+	job := enqueueRandomJob(t, kind, kind)
+
+	_, err := testQueries.EnqueueJob(context.Background(), EnqueueJobParams{
+		Kind:        kind,
+		Payload:     json.RawMessage(`{}`),
+		UniqueKey:   pgtype.Text{String: kind, Valid: true},
+		MaxAttempts: 3,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	claimed := claimTestJobs(t, kind)
+	require.Len(t, claimed, 1)
+	n, err := testQueries.CompleteJob(context.Background(), CompleteJobParams{ID: job.ID, Attempts: claimed[0].Attempts})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	// the key is free again once the job finished
+	enqueueRandomJob(t, kind, kind)
+}
+
+func TestClaimJobs(t *testing.T) {
+	kind := "test_" + util.RandomOwner()
+	job := enqueueRandomJob(t, kind, "")
+
+	claimed := claimTestJobs(t, kind)
+	require.Len(t, claimed, 1)
+	require.Equal(t, job.ID, claimed[0].ID)
+	require.Equal(t, JobStatusRunning, claimed[0].Status)
+	require.EqualValues(t, 1, claimed[0].Attempts)
+	require.True(t, claimed[0].LockedUntil.Valid)
+
+	// locked until the visibility timeout expires
+	require.Empty(t, claimTestJobs(t, kind))
+
+	n, err := testQueries.RetryJob(context.Background(), RetryJobParams{
+		ID:        job.ID,
+		Attempts:  1,
+		RunAt:     pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+		LastError: pgtype.Text{String: "boom", Valid: true},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	claimed = claimTestJobs(t, kind)
+	require.Len(t, claimed, 1)
+	require.EqualValues(t, 2, claimed[0].Attempts)
+	require.Equal(t, "boom", claimed[0].LastError.String)
+
+	// a stale attempt can no longer record its outcome
+	n, err = testQueries.CompleteJob(context.Background(), CompleteJobParams{ID: job.ID, Attempts: 1})
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	n, err = testQueries.FailJob(context.Background(), FailJobParams{
+		ID:        job.ID,
+		Attempts:  2,
+		LastError: pgtype.Text{String: "gave up", Valid: true},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	failed, err := testQueries.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusFailed, failed.Status)
+	require.True(t, failed.FinishedAt.Valid)
+	require.Empty(t, claimTestJobs(t, kind))
+}
+
+func TestUpdateAccountStatusTxEnqueuesChainVerification(t *testing.T) {
+	store := NewStore(testPool)
+	account := CreateRandomAccount(t)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:     account.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	var job Job
+	err = testPool.QueryRow(context.Background(),
+		"SELECT id, kind, payload FROM jobs WHERE unique_key = $1", accountChainJobKey(account.ID),
+	).Scan(&job.ID, &job.Kind, &job.Payload)
+	require.NoError(t, err)
+	require.Equal(t, JobVerifyAccountChain, job.Kind)
+
+	var payload VerifyAccountChainJobV1
+	require.NoError(t, json.Unmarshal(job.Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomJournal posts one entry per amount to accountID inside a single journal.
+func createRandomJournal(t *testing.T, accountID int64, amounts ...int64) []Entry {
+	store := NewStore(testPool).(*SQLStore)
+	ctx := context.Background()
+
+	var entries []Entry
+	err := store.execTx(ctx, TxOptions{}, func(q *Queries) error {
+		journal, err := q.CreateJournal(ctx, pgtype.Int8{})
+		if err != nil {
+			return err
+		}
+
+		for _, amount := range amounts {
+			entry, err := createChainedEntry(ctx, q, journal.ID, accountID, amount)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, len(amounts))
+
+	for _, entry := range entries {
+		require.NotZero(t, entry.ID)
+		require.NotZero(t, entry.CreatedAt)
+		require.Equal(t, entries[0].JournalID, entry.JournalID)
+	}
+
+	return entries
+}
+
+func TestCreateJournal(t *testing.T) {
+	account := CreateRandomAccount(t)
+	entries := createRandomJournal(t, account.ID, 10, -10)
+
+	journal, err := testQueries.GetJournal(context.Background(), entries[0].JournalID)
+	require.NoError(t, err)
+	require.False(t, journal.TransferID.Valid)
+
+	posted, err := testQueries.ListEntriesByJournal(context.Background(), journal.ID)
+	require.NoError(t, err)
+	require.Equal(t, entries, posted)
+}
+
+func TestUnbalancedJournal(t *testing.T) {
+	store := NewStore(testPool).(*SQLStore)
+	account := CreateRandomAccount(t)
+	ctx := context.Background()
+
+	var journalID int64
+	err := store.execTx(ctx, TxOptions{}, func(q *Queries) error {
+		journal, err := q.CreateJournal(ctx, pgtype.Int8{})
+		if err != nil {
+			return err
+		}
+		journalID = journal.ID
+
+		_, err = createChainedEntry(ctx, q, journal.ID, account.ID, 10)
+		return err
+	})
+	require.Error(t, err)
+
+	_, err = testQueries.GetJournal(ctx, journalID)
+	require.Error(t, err)
+}
+
+func TestListUnbalancedJournals(t *testing.T) {
+	account := CreateRandomAccount(t)
+	ctx := context.Background()
+
+	balanced := createRandomJournal(t, account.ID, 10, -10)
+
+	// session_replication_role = replica skips the balance trigger, as a
+	// manual edit of the ledger might
+	tx, err := testPool.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "SET LOCAL session_replication_role = replica")
+	require.NoError(t, err)
+	q := New(tx)
+	journal, err := q.CreateJournal(ctx, pgtype.Int8{})
+	require.NoError(t, err)
+	entry, err := q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    25,
+		JournalID: journal.ID,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	defer testQueries.DeleteEntry(ctx, entry.ID)
+
+	rows, err := testQueries.ListUnbalancedJournals(ctx)
+	require.NoError(t, err)
+	require.Contains(t, rows, ListUnbalancedJournalsRow{JournalID: journal.ID, Currency: account.Currency, Total: 25})
+	for _, row := range rows {
+		require.NotEqual(t, balanced[0].JournalID, row.JournalID)
+	}
+}
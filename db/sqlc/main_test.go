@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 	"testing"
@@ -20,7 +19,7 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatal("failed to load config: ", err)
 	}
-	testPool, err = pgxpool.New(context.Background(), fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable", config.DBUser, config.DBPassword, config.DBHost, config.DBPort, config.DBName))
+	testPool, err = pgxpool.New(context.Background(), config.DatabaseURL())
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
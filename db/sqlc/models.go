@@ -5,6 +5,8 @@
 package db
 
 import (
+	"encoding/json"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -14,6 +16,28 @@ type Account struct {
 	Balance   int64              `json:"balance"`
 	Currency  string             `json:"currency"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// incremented by every update, served as the ETag
+	Version int64 `json:"version"`
+}
+
+type AuditLog struct {
+	ID     int64  `json:"id"`
+	Actor  string `json:"actor"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	// affected resources as kind:id
+	TargetIds []string `json:"target_ids"`
+	RequestID string   `json:"request_id"`
+	ClientIp  string   `json:"client_ip"`
+	// committed when written in the business transaction, otherwise success or failure
+	Outcome string `json:"outcome"`
+	// null when written before the response was sent
+	StatusCode pgtype.Int4 `json:"status_code"`
+	// request body with secrets redacted
+	RequestBody json.RawMessage    `json:"request_body" swaggertype:"object"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type Entry struct {
@@ -22,6 +46,87 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64              `json:"amount"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	JournalID int64              `json:"journal_id"`
+	// hash of the previous entry of the same account
+	PrevHash []byte `json:"prev_hash" format:"base64" swaggertype:"string"`
+	// sha256 over the entry contents and prev_hash, null for entries written before chaining
+	Hash []byte `json:"hash" format:"base64" swaggertype:"string"`
+}
+
+// single row recording the last entry written before chaining; every later entry must have a hash
+type EntryChainCutoff struct {
+	LastLegacyEntryID int64 `json:"last_legacy_entry_id"`
+}
+
+type Job struct {
+	ID      int64           `json:"id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// deduplicates jobs while one is pending or running
+	UniqueKey pgtype.Text `json:"unique_key"`
+	// pending, running, succeeded or failed
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	MaxAttempts int32              `json:"max_attempts"`
+	RunAt       pgtype.Timestamptz `json:"run_at"`
+	// visibility timeout of the running attempt
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	LastError   pgtype.Text        `json:"last_error"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+}
+
+type Journal struct {
+	ID int64 `json:"id"`
+	// null for journals not produced by a transfer
+	TransferID pgtype.Int8        `json:"transfer_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Notification struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// transfer_sent or transfer_received
+	EventType  string             `json:"event_type"`
+	TransferID int64              `json:"transfer_id"`
+	Title      string             `json:"title"`
+	Body       string             `json:"body"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	ReadAt     pgtype.Timestamptz `json:"read_at"`
+}
+
+type NotificationPreference struct {
+	Username  string `json:"username"`
+	EventType string `json:"event_type"`
+	Email     bool   `json:"email"`
+	InApp     bool   `json:"in_app"`
+	// transfers below this amount are not notified
+	MinAmount int64              `json:"min_amount"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type OutboxEvent struct {
+	ID            int64  `json:"id"`
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   int64  `json:"aggregate_id"`
+	EventType     string `json:"event_type"`
+	// schema version of the payload
+	Version   int32              `json:"version"`
+	Payload   []byte             `json:"payload"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// null until the relay has handed the event to the publisher
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	// the relay that claimed the event publishes it until then, after which another relay may claim it
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+}
+
+// token buckets shared by all instances; unlogged since losing them only resets limits
+type RateLimitBucket struct {
+	Key    string  `json:"key"`
+	Tokens float64 `json:"tokens"`
+	// whether the last request took a token
+	Allowed   bool               `json:"allowed"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Transfer struct {
@@ -41,3 +146,30 @@ type User struct {
 	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
 }
+
+type WebhookDelivery struct {
+	ID             int64 `json:"id"`
+	SubscriptionID int64 `json:"subscription_id"`
+	// id of the relayed outbox event
+	EventID   int64  `json:"event_id"`
+	EventType string `json:"event_type"`
+	Payload   []byte `json:"payload"`
+	// pending, delivered or dead
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	ResponseStatus pgtype.Int4        `json:"response_status"`
+	LastError      pgtype.Text        `json:"last_error"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+}
+
+type WebhookSubscription struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	Url       string `json:"url"`
+	EventType string `json:"event_type"`
+	// key of the HMAC-SHA256 request signature
+	Secret    string             `json:"secret"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package db
+
+import "context"
+
+// Notification event types.
+const (
+	NotificationTransferSent     = "transfer_sent"
+	NotificationTransferReceived = "transfer_received"
+)
+
+// NotificationEventTypes lists the events users can set preferences for.
+var NotificationEventTypes = []string{NotificationTransferSent, NotificationTransferReceived}
+
+// DefaultNotificationPreference applies to events a user has not configured:
+// every transfer is notified over both channels.
+func DefaultNotificationPreference(username, eventType string) NotificationPreference {
+	return NotificationPreference{
+		Username:  username,
+		EventType: eventType,
+		Email:     true,
+		InApp:     true,
+	}
+}
+
+// addTransferNotificationJobs enqueues the notifications of both sides of a
+// transfer within the caller's transaction.
+func addTransferNotificationJobs(ctx context.Context, q *Queries, transfer Transfer) error {
+	sides := []NotifyTransferJobV1{
+		{TransferID: transfer.ID, AccountID: transfer.FromAccountID, EventType: NotificationTransferSent},
+		{TransferID: transfer.ID, AccountID: transfer.ToAccountID, EventType: NotificationTransferReceived},
+	}
+	for _, side := range sides {
+		if err := addJob(ctx, q, JobNotifyTransfer, transferNotificationJobKey(side.TransferID, side.EventType), side); err != nil {
+			return err
+		}
+	}
+	return nil
+}
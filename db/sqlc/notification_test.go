@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	user := createRandomUser(t)
+	account1 := CreateRandomAccount(t)
+	account2 := CreateRandomAccount(t)
+	transfer := createRandomTransfer(t, account1.ID, account2.ID)
+
+	arg := CreateNotificationParams{
+		Username:   user.Username,
+		EventType:  NotificationTransferReceived,
+		TransferID: transfer.ID,
+		Title:      "Transfer received",
+		Body:       "received",
+	}
+	notification, err := testQueries.CreateNotification(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, notification.ReadAt.Valid)
+
+	// one notification per user, event and transfer
+	_, err = testQueries.CreateNotification(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	unread, err := testQueries.ListNotifications(context.Background(), ListNotificationsParams{
+		Username:   user.Username,
+		UnreadOnly: true,
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Notification{notification}, unread)
+
+	_, err = testQueries.MarkNotificationRead(context.Background(), MarkNotificationReadParams{ID: notification.ID, Username: "someone_else"})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	read, err := testQueries.MarkNotificationRead(context.Background(), MarkNotificationReadParams{ID: notification.ID, Username: user.Username})
+	require.NoError(t, err)
+	require.True(t, read.ReadAt.Valid)
+
+	again, err := testQueries.MarkNotificationRead(context.Background(), MarkNotificationReadParams{ID: notification.ID, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, read.ReadAt, again.ReadAt)
+
+	unread, err = testQueries.ListNotifications(context.Background(), ListNotificationsParams{
+		Username:   user.Username,
+		UnreadOnly: true,
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, unread)
+}
+
+func TestUpsertNotificationPreference(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.GetNotificationPreference(context.Background(), GetNotificationPreferenceParams{
+		Username:  user.Username,
+		EventType: NotificationTransferSent,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	arg := UpsertNotificationPreferenceParams{
+		Username:  user.Username,
+		EventType: NotificationTransferSent,
+		Email:     true,
+		MinAmount: 100,
+	}
+	_, err = testQueries.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.Email, arg.InApp, arg.MinAmount = false, true, 0
+	preference, err := testQueries.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, preference.Email)
+	require.True(t, preference.InApp)
+	require.Zero(t, preference.MinAmount)
+
+	preferences, err := testQueries.ListNotificationPreferences(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, []NotificationPreference{preference}, preferences)
+}
+
+func TestTransferTxEnqueuesNotifications(t *testing.T) {
+	store := NewStore(testPool)
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	for _, eventType := range NotificationEventTypes {
+		var kind string
+		err := testPool.QueryRow(context.Background(),
+			"SELECT kind FROM jobs WHERE unique_key = $1", transferNotificationJobKey(result.Transfer.ID, eventType),
+		).Scan(&kind)
+		require.NoError(t, err)
+		require.Equal(t, JobNotifyTransfer, kind)
+	}
+}
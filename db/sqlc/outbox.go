@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Aggregate types of outbox events.
+const (
+	AggregateAccount  = "account"
+	AggregateTransfer = "transfer"
+)
+
+// outboxPublishTimeout bounds the publishing of a claimed batch. Events stay
+// leased for twice as long, so no other relay claims them meanwhile.
+const outboxPublishTimeout = 30 * time.Second
+
+// Outbox event types.
+const (
+	EventTransferCreated  = "TransferCreated"
+	EventAccountCreated   = "AccountCreated"
+	EventAccountActivated = "AccountActivated"
+	EventAccountFrozen    = "AccountFrozen"
+	EventAccountClosed    = "AccountClosed"
+)
+
+// TransferCreatedV1 is the version 1 payload of EventTransferCreated.
+type TransferCreatedV1 struct {
+	TransferID    int64     `json:"transfer_id"`
+	JournalID     int64     `json:"journal_id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AccountCreatedV1 is the version 1 payload of EventAccountCreated.
+type AccountCreatedV1 struct {
+	AccountID int64     `json:"account_id"`
+	Owner     string    `json:"owner"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountStatusChangedV1 is the version 1 payload of the account status events.
+type AccountStatusChangedV1 struct {
+	AccountID      int64  `json:"account_id"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+}
+
+// addOutboxEvent records an event in the outbox within the caller's transaction.
+func addOutboxEvent(ctx context.Context, q *Queries, aggregateType string, aggregateID int64, eventType string, version int32, payload any) (OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Version:       version,
+		Payload:       data,
+	})
+}
+
+// ProcessOutbox leases up to limit unpublished events, hands them to publish
+// in order and marks every successfully published event. No transaction is
+// held while publishing: the lease keeps other relays off the events, and
+// publish gets a context that ends well before the lease does. ProcessOutbox
+// stops at the first publish error, releasing the events not published yet,
+// and returns that error along with the number of events published. An event
+// whose lease expires before it is marked is published again, which
+// at-least-once delivery allows.
+func (store *SQLStore) ProcessOutbox(ctx context.Context, limit int32, publish func(context.Context, OutboxEvent) error) (int, error) {
+	events, err := store.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(2 * outboxPublishTimeout), Valid: true},
+		Limit:       limit,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+	defer cancel()
+
+	for i, event := range events {
+		if err := publish(publishCtx, event); err != nil {
+			ids := make([]int64, 0, len(events)-i)
+			for _, event := range events[i:] {
+				ids = append(ids, event.ID)
+			}
+			// the next batch may retry them right away
+			if releaseErr := store.ReleaseOutboxEvents(ctx, ids); releaseErr != nil {
+				return i, errors.Join(err, releaseErr)
+			}
+			return i, err
+		}
+
+		if err := store.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(events), nil
+}
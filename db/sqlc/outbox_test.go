@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+
+	var payload AccountCreatedV1
+	event := requireOutboxEvent(t, store, AggregateAccount, account.ID, EventAccountCreated)
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+	require.Equal(t, account.Currency, payload.Currency)
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testPool)
+	account := CreateRandomAccount(t)
+
+	frozen, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:     account.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	var payload AccountStatusChangedV1
+	event := requireOutboxEvent(t, store, AggregateAccount, account.ID, EventAccountFrozen)
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, AccountStatusActive, payload.PreviousStatus)
+	require.Equal(t, AccountStatusFrozen, payload.Status)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:     account.ID,
+		Status: "deleted",
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateAccountStatusTxIfVersion(t *testing.T) {
+	store := NewStore(testPool)
+	account := CreateRandomAccount(t)
+	require.EqualValues(t, 1, account.Version)
+
+	frozen, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:        account.ID,
+		Status:    AccountStatusFrozen,
+		IfVersion: account.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Version+1, frozen.Version)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:        account.ID,
+		Status:    AccountStatusClosed,
+		IfVersion: account.Version,
+	})
+	require.Equal(t, apperr.CodePreconditionFailed, apperr.From(err).Code)
+
+	current, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, current.Status)
+	require.Equal(t, frozen.Version, current.Version)
+}
+
+// requireOutboxEvent drains the outbox and returns the event recorded for the aggregate.
+func requireOutboxEvent(t *testing.T, store Store, aggregateType string, aggregateID int64, eventType string) OutboxEvent {
+	var found []OutboxEvent
+	for {
+		published, err := store.ProcessOutbox(context.Background(), 100, func(_ context.Context, event OutboxEvent) error {
+			if event.AggregateType == aggregateType && event.AggregateID == aggregateID && event.EventType == eventType {
+				found = append(found, event)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		if published == 0 {
+			break
+		}
+	}
+
+	require.Len(t, found, 1)
+	require.Equal(t, int32(1), found[0].Version)
+	return found[0]
+}
+
+func TestProcessOutboxReleasesUnpublished(t *testing.T) {
+	store := NewStore(testPool)
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	errUnavailable := errors.New("broker unavailable")
+	for {
+		_, err := store.ProcessOutbox(context.Background(), 100, func(_ context.Context, event OutboxEvent) error {
+			if event.AggregateType == AggregateAccount && event.AggregateID == account.ID {
+				return errUnavailable
+			}
+			return nil
+		})
+		if errors.Is(err, errUnavailable) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	// released events are claimed again without waiting for the lease
+	requireOutboxEvent(t, store, AggregateAccount, account.ID, EventAccountCreated)
+}
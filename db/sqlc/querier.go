@@ -6,32 +6,83 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	// Claims due jobs of the given kinds, and running ones whose visibility
+	// timeout expired, for their next attempt.
+	ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]Job, error)
+	// Leases the oldest unpublished events that no other relay holds.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error)
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateJournal(ctx context.Context, transferID pgtype.Int8) (Journal, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	DeleteEntry(ctx context.Context, id int64) error
+	DeleteStaleRateLimitBuckets(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error)
 	DeleteTransfer(ctx context.Context, id int64) error
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	// Renews the lease of a claimed delivery, provided it still holds the lease
+	// it was claimed with.
+	ExtendWebhookDeliveryLease(ctx context.Context, arg ExtendWebhookDeliveryLeaseParams) (WebhookDelivery, error)
+	FailJob(ctx context.Context, arg FailJobParams) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	// Returns the id of the last entry written before chaining.
+	GetEntryChainCutoff(ctx context.Context) (int64, error)
+	GetJob(ctx context.Context, id int64) (Job, error)
+	GetJournal(ctx context.Context, id int64) (Journal, error)
+	GetJournalByTransfer(ctx context.Context, transferID pgtype.Int8) (Journal, error)
+	GetLastAccountEntry(ctx context.Context, accountID int64) (Entry, error)
+	GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (NotificationPreference, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAuditLogByTarget(ctx context.Context, arg ListAuditLogByTargetParams) ([]AuditLog, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
 	ListEntriesByAccount(ctx context.Context, accountID int64) ([]Entry, error)
+	ListEntriesByAccountAfter(ctx context.Context, arg ListEntriesByAccountAfterParams) ([]Entry, error)
+	ListEntriesByJournal(ctx context.Context, journalID int64) ([]Entry, error)
+	ListJobsByStatus(ctx context.Context, arg ListJobsByStatusParams) ([]Job, error)
+	ListNotificationPreferences(ctx context.Context, username string) ([]NotificationPreference, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
 	ListTransfersBetweenAccounts(ctx context.Context, arg ListTransfersBetweenAccountsParams) ([]Transfer, error)
 	ListTransfersByAccount(ctx context.Context, fromAccountID int64) ([]Transfer, error)
+	// Journals whose entries do not sum to zero per currency. The deferred
+	// trigger rejects them, so any row means the ledger was edited around it.
+	ListUnbalancedJournals(ctx context.Context) ([]ListUnbalancedJournalsRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (Notification, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	NotifyAccountEvent(ctx context.Context, payload string) error
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (WebhookDelivery, error)
+	ReleaseOutboxEvents(ctx context.Context, ids []int64) error
+	RetryJob(ctx context.Context, arg RetryJobParams) (int64, error)
+	// Refills the bucket for the time since its last update and takes one token
+	// if a whole one is available. The row lock of the upsert serializes
+	// concurrent requests for the same key.
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
 	UpdateTransferAmount(ctx context.Context, arg UpdateTransferAmountParams) error
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error)
 }
 
 var _ Querier = (*Queries)(nil)
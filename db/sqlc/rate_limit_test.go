@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	arg := TakeRateLimitTokenParams{
+		Key:   "test:" + util.RandomOwner(),
+		Burst: 2,
+		Rate:  0.001,
+	}
+
+	for i, allowed := range []bool{true, true, false, false} {
+		row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, allowed, row.Allowed, "request %d", i)
+		require.Less(t, row.Tokens, float64(1))
+	}
+}
+
+func TestDeleteStaleRateLimitBuckets(t *testing.T) {
+	arg := TakeRateLimitTokenParams{Key: "test:" + util.RandomOwner(), Burst: 1, Rate: 1}
+	_, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteStaleRateLimitBuckets(context.Background(), pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+}
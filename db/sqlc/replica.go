@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// primaryPool names the primary in read metrics.
+const primaryPool = "primary"
+
+// ReplicaSet holds the read replicas of a store. Reads go round robin to the
+// replicas marked healthy; Monitor keeps the marks current.
+type ReplicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	queries *Queries
+	healthy atomic.Bool
+}
+
+func NewReplicaSet() *ReplicaSet {
+	return &ReplicaSet{}
+}
+
+// Add adds a healthy replica; name labels its logs and metrics. Replicas
+// must be added before the set is used.
+func (set *ReplicaSet) Add(name string, pool *pgxpool.Pool) {
+	r := &replica{name: name, pool: pool, queries: New(pool)}
+	r.healthy.Store(true)
+	metrics.SetReplicaHealthy(name, true)
+	set.replicas = append(set.replicas, r)
+}
+
+// Len returns the number of replicas.
+func (set *ReplicaSet) Len() int {
+	return len(set.replicas)
+}
+
+// Close closes the pools of all replicas.
+func (set *ReplicaSet) Close() {
+	for _, r := range set.replicas {
+		r.pool.Close()
+	}
+}
+
+// Monitor pings every replica each interval until ctx is done, taking
+// replicas that do not answer out of rotation and returning those that
+// recover.
+func (set *ReplicaSet) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, r := range set.replicas {
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := r.pool.Ping(pingCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			set.mark(ctx, r, err)
+		}
+	}
+}
+
+// Check fails when no replica is healthy, meaning all reads go to the
+// primary.
+func (set *ReplicaSet) Check(ctx context.Context) error {
+	if set.pick() == nil {
+		return fmt.Errorf("none of %d replicas is healthy, reading from the primary", len(set.replicas))
+	}
+	return nil
+}
+
+// pick returns the next healthy replica, or nil if there is none.
+func (set *ReplicaSet) pick() *replica {
+	if set == nil || len(set.replicas) == 0 {
+		return nil
+	}
+
+	n := uint64(len(set.replicas))
+	start := set.next.Add(1)
+	for i := range n {
+		if r := set.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// mark takes r out of rotation when err is set and returns it otherwise,
+// logging changes.
+func (set *ReplicaSet) mark(ctx context.Context, r *replica, err error) {
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	metrics.SetReplicaHealthy(r.name, healthy)
+
+	logger := logging.FromContext(ctx)
+	if healthy {
+		logger.Info("replica recovered", "replica", r.name)
+	} else {
+		logger.Warn("replica unhealthy, skipping it for reads", slog.String("replica", r.name), slog.Any("error", err))
+	}
+}
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads returns a copy of ctx whose reads go to the primary, so
+// they see every write committed before them, including ones not yet
+// replayed by the replicas.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads reports whether ctx asks for primary reads.
+func PrimaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primary
+}
+
+// read runs query on a healthy replica, or on the primary when there is none
+// or ctx asks for primary reads. A replica failing with anything other than
+// a query result such as pgx.ErrNoRows is retried on the primary; connection
+// errors also take the replica out of rotation until Monitor sees it again.
+// Transactions never come here: execTx queries the primary.
+func read[T any](ctx context.Context, store *SQLStore, query func(q *Queries) (T, error)) (T, error) {
+	r := store.replicas.pick()
+	if r == nil || PrimaryReads(ctx) {
+		metrics.ReadServed(primaryPool, false)
+		return query(store.Queries)
+	}
+
+	result, err := query(r.queries)
+	if err == nil || errors.Is(err, pgx.ErrNoRows) || ctx.Err() != nil {
+		metrics.ReadServed(r.name, false)
+		return result, err
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		store.replicas.mark(ctx, r, err)
+	}
+	logging.FromContext(ctx).Warn("replica read failed, retrying on the primary", "replica", r.name, "error", err)
+	metrics.ReadServed(primaryPool, true)
+	return query(store.Queries)
+}
+
+// The reads below may be served by a replica, so they can lag behind the
+// primary slightly.
+
+func (store *SQLStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return read(ctx, store, func(q *Queries) (Account, error) { return q.GetAccount(ctx, id) })
+}
+
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return read(ctx, store, func(q *Queries) ([]Account, error) { return q.ListAccounts(ctx, arg) })
+}
+
+func (store *SQLStore) ListAuditLogByTarget(ctx context.Context, arg ListAuditLogByTargetParams) ([]AuditLog, error) {
+	return read(ctx, store, func(q *Queries) ([]AuditLog, error) { return q.ListAuditLogByTarget(ctx, arg) })
+}
+
+func (store *SQLStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	return read(ctx, store, func(q *Queries) (Entry, error) { return q.GetEntry(ctx, id) })
+}
+
+func (store *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return read(ctx, store, func(q *Queries) ([]Entry, error) { return q.ListEntries(ctx, arg) })
+}
+
+func (store *SQLStore) ListEntriesByAccount(ctx context.Context, accountID int64) ([]Entry, error) {
+	return read(ctx, store, func(q *Queries) ([]Entry, error) { return q.ListEntriesByAccount(ctx, accountID) })
+}
+
+func (store *SQLStore) ListEntriesByJournal(ctx context.Context, journalID int64) ([]Entry, error) {
+	return read(ctx, store, func(q *Queries) ([]Entry, error) { return q.ListEntriesByJournal(ctx, journalID) })
+}
+
+func (store *SQLStore) GetJournal(ctx context.Context, id int64) (Journal, error) {
+	return read(ctx, store, func(q *Queries) (Journal, error) { return q.GetJournal(ctx, id) })
+}
+
+func (store *SQLStore) GetJournalByTransfer(ctx context.Context, transferID pgtype.Int8) (Journal, error) {
+	return read(ctx, store, func(q *Queries) (Journal, error) { return q.GetJournalByTransfer(ctx, transferID) })
+}
+
+func (store *SQLStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	return read(ctx, store, func(q *Queries) (Transfer, error) { return q.GetTransfer(ctx, id) })
+}
+
+func (store *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return read(ctx, store, func(q *Queries) ([]Transfer, error) { return q.ListTransfers(ctx, arg) })
+}
+
+func (store *SQLStore) ListTransfersBetweenAccounts(ctx context.Context, arg ListTransfersBetweenAccountsParams) ([]Transfer, error) {
+	return read(ctx, store, func(q *Queries) ([]Transfer, error) { return q.ListTransfersBetweenAccounts(ctx, arg) })
+}
+
+func (store *SQLStore) ListTransfersByAccount(ctx context.Context, fromAccountID int64) ([]Transfer, error) {
+	return read(ctx, store, func(q *Queries) ([]Transfer, error) { return q.ListTransfersByAccount(ctx, fromAccountID) })
+}
+
+func (store *SQLStore) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	return read(ctx, store, func(q *Queries) ([]User, error) { return q.ListUsers(ctx, arg) })
+}
+
+func (store *SQLStore) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return read(ctx, store, func(q *Queries) ([]WebhookDelivery, error) { return q.ListWebhookDeliveries(ctx, arg) })
+}
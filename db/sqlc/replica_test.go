@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB answers every QueryRow with err and counts the calls.
+type fakeDB struct {
+	DBTX
+	err   error
+	calls int
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	db.calls++
+	return fakeRow{err: db.err}
+}
+
+type fakeRow struct{ err error }
+
+func (row fakeRow) Scan(dest ...any) error { return row.err }
+
+func newFakeReplica(name string, db *fakeDB) *replica {
+	r := &replica{name: name, queries: New(db)}
+	r.healthy.Store(true)
+	return r
+}
+
+func TestRead(t *testing.T) {
+	testCases := []struct {
+		name       string
+		ctx        context.Context
+		replicaErr error
+		unhealthy  bool
+		check      func(t *testing.T, err error, primary, replica *fakeDB, r *replica)
+	}{
+		{
+			name: "Replica",
+			ctx:  context.Background(),
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.NoError(t, err)
+				require.Equal(t, 0, primary.calls)
+				require.Equal(t, 1, replica.calls)
+			},
+		},
+		{
+			name: "PrimaryReads",
+			ctx:  WithPrimaryReads(context.Background()),
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.NoError(t, err)
+				require.Equal(t, 1, primary.calls)
+				require.Equal(t, 0, replica.calls)
+			},
+		},
+		{
+			name:      "NoHealthyReplica",
+			ctx:       context.Background(),
+			unhealthy: true,
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.NoError(t, err)
+				require.Equal(t, 1, primary.calls)
+				require.Equal(t, 0, replica.calls)
+			},
+		},
+		{
+			name:       "NotFoundOnReplica",
+			ctx:        context.Background(),
+			replicaErr: pgx.ErrNoRows,
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.ErrorIs(t, err, pgx.ErrNoRows)
+				require.Equal(t, 0, primary.calls)
+				require.True(t, r.healthy.Load())
+			},
+		},
+		{
+			name:       "ConnectionError",
+			ctx:        context.Background(),
+			replicaErr: errors.New("connection refused"),
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.NoError(t, err)
+				require.Equal(t, 1, primary.calls)
+				require.False(t, r.healthy.Load())
+			},
+		},
+		{
+			name:       "RecoveryConflict",
+			ctx:        context.Background(),
+			replicaErr: &pgconn.PgError{Code: serializationFailure},
+			check: func(t *testing.T, err error, primary, replica *fakeDB, r *replica) {
+				require.NoError(t, err)
+				require.Equal(t, 1, primary.calls)
+				require.True(t, r.healthy.Load())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &fakeDB{}
+			replicaDB := &fakeDB{err: tc.replicaErr}
+			r := newFakeReplica("replica_1", replicaDB)
+			r.healthy.Store(!tc.unhealthy)
+
+			store := &SQLStore{Queries: New(primary), replicas: &ReplicaSet{replicas: []*replica{r}}}
+			_, err := store.GetTransfer(tc.ctx, 1)
+			tc.check(t, err, primary, replicaDB, r)
+		})
+	}
+}
+
+func TestReplicaSetPick(t *testing.T) {
+	var set *ReplicaSet
+	require.Nil(t, set.pick())
+
+	a := newFakeReplica("replica_1", &fakeDB{})
+	b := newFakeReplica("replica_2", &fakeDB{})
+	set = &ReplicaSet{replicas: []*replica{a, b}}
+
+	picked := map[string]int{}
+	for range 4 {
+		picked[set.pick().name]++
+	}
+	require.Equal(t, map[string]int{"replica_1": 2, "replica_2": 2}, picked)
+
+	set.mark(context.Background(), a, errors.New("connection refused"))
+	for range 2 {
+		require.Equal(t, b, set.pick())
+	}
+	require.NoError(t, set.Check(context.Background()))
+
+	set.mark(context.Background(), b, errors.New("connection refused"))
+	require.Nil(t, set.pick())
+	require.Error(t, set.Check(context.Background()))
+
+	set.mark(context.Background(), a, nil)
+	require.Equal(t, a, set.pick())
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultTxRetries = 3
+	baseTxBackoff    = 10 * time.Millisecond
+	maxTxBackoff     = 500 * time.Millisecond
+)
+
+// SQLSTATEs after which the whole transaction can be run again.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// retryableTxError reports whether err is a serialization failure or a
+// deadlock, along with the error type used in retry metrics.
+func retryableTxError(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+	switch pgErr.Code {
+	case serializationFailure:
+		return "serialization_failure", true
+	case deadlockDetected:
+		return "deadlock", true
+	}
+	return "", false
+}
+
+// txBackoff returns a random delay of up to 10ms doubled per attempt, capped
+// at 500ms, so that transactions that conflicted do not retry in lockstep.
+func txBackoff(attempt int) time.Duration {
+	ceiling := maxTxBackoff
+	if attempt < 6 {
+		ceiling = min(baseTxBackoff<<attempt, maxTxBackoff)
+	}
+	return time.Duration(rand.Int64N(int64(ceiling))) + time.Millisecond
+}
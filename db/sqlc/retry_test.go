@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryableTxError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		errorType string
+		retryable bool
+	}{
+		{name: "SerializationFailure", err: &pgconn.PgError{Code: serializationFailure}, errorType: "serialization_failure", retryable: true},
+		{name: "Deadlock", err: fmt.Errorf("tx err: %w", &pgconn.PgError{Code: deadlockDetected}), errorType: "deadlock", retryable: true},
+		{name: "UniqueViolation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "Other", err: errors.New("connection reset")},
+		{name: "Nil"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errorType, retryable := retryableTxError(tc.err)
+			require.Equal(t, tc.retryable, retryable)
+			require.Equal(t, tc.errorType, errorType)
+		})
+	}
+}
+
+func TestTxBackoff(t *testing.T) {
+	for attempt := range 10 {
+		for range 100 {
+			delay := txBackoff(attempt)
+			require.Positive(t, delay)
+			require.LessOrEqual(t, delay, maxTxBackoff+time.Millisecond)
+		}
+	}
+}
+
+func TestExecTxRetry(t *testing.T) {
+	ctx := context.Background()
+	conflict := &pgconn.PgError{Code: serializationFailure}
+
+	failing := func(failures int, attempts *int) func(*Queries) error {
+		return func(q *Queries) error {
+			*attempts++
+			if *attempts <= failures {
+				return conflict
+			}
+			return nil
+		}
+	}
+
+	store := NewStore(testPool, WithTxRetries(3)).(*SQLStore)
+
+	attempts := 0
+	require.NoError(t, store.execTx(ctx, TxOptions{Name: "test"}, failing(2, &attempts)))
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	err := store.execTx(ctx, TxOptions{Name: "test"}, failing(10, &attempts))
+	require.ErrorIs(t, err, conflict)
+	require.Equal(t, 4, attempts, "one attempt plus three retries")
+
+	attempts = 0
+	err = store.execTx(ctx, TxOptions{Name: "test"}, func(q *Queries) error {
+		attempts++
+		return errors.New("boom")
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, attempts, "other errors are not retried")
+
+	attempts = 0
+	err = NewStore(testPool, WithTxRetries(0)).(*SQLStore).execTx(ctx, TxOptions{Name: "test"}, failing(1, &attempts))
+	require.ErrorIs(t, err, conflict)
+	require.Equal(t, 1, attempts)
+}
+
+func TestExecTxReadOnly(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool).(*SQLStore)
+	owner := createRandomUser(t).Username
+
+	err := store.execTx(ctx, TxOptions{ReadOnly: true}, func(q *Queries) error {
+		_, err := q.CreateAccount(ctx, CreateAccountParams{
+			Owner:    owner,
+			Currency: "USD",
+		})
+		return err
+	})
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, "25006", pgErr.Code, "read_only_sql_transaction")
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaMigration is the state golang-migrate keeps in schema_migrations.
+type SchemaMigration struct {
+	Version int64
+	Dirty   bool
+}
+
+// Ping checks that the database is reachable.
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPool.Ping(ctx)
+}
+
+// GetSchemaMigration returns the applied migration version, or version 0 on
+// a database that was never migrated.
+func (store *SQLStore) GetSchemaMigration(ctx context.Context) (SchemaMigration, error) {
+	var migration SchemaMigration
+	err := store.connPool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&migration.Version, &migration.Dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SchemaMigration{}, nil
+	}
+	return migration, err
+}
@@ -3,49 +3,163 @@ package db
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Store interface {
 	Querier
 	//TXs
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error)
+	ProcessOutbox(ctx context.Context, limit int32, publish func(context.Context, OutboxEvent) error) (int, error)
+	ListenAccountEvents(ctx context.Context, handle func(AccountEvent)) error
+	WriteAuditRecord(ctx context.Context, record *AuditRecord, statusCode int) error
+	VerifyAccountChain(ctx context.Context, accountID int64) (ChainVerification, error)
+	//Health
+	Ping(ctx context.Context) error
+	GetSchemaMigration(ctx context.Context) (SchemaMigration, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
 type SQLStore struct {
-	connPool *pgxpool.Pool
+	connPool     *pgxpool.Pool
+	maxTxRetries int
+	replicas     *ReplicaSet
 	*Queries
 }
 
 var _ Store = (*SQLStore)(nil)
 
+// StoreOption configures a SQLStore.
+type StoreOption func(*SQLStore)
+
+// WithTxRetries sets how many times a transaction that failed with a
+// serialization failure or deadlock is retried. Zero disables retries.
+func WithTxRetries(n int) StoreOption {
+	return func(store *SQLStore) {
+		store.maxTxRetries = max(n, 0)
+	}
+}
+
+// WithReplicas routes reads made outside of transactions to replicas.
+func WithReplicas(replicas *ReplicaSet) StoreOption {
+	return func(store *SQLStore) {
+		store.replicas = replicas
+	}
+}
+
 // NewStore creates a new store
-func NewStore(connPool *pgxpool.Pool) Store {
-	return &SQLStore{
-		connPool: connPool,
-		Queries:  New(connPool),
+func NewStore(connPool *pgxpool.Pool, options ...StoreOption) Store {
+	store := &SQLStore{
+		connPool:     connPool,
+		maxTxRetries: defaultTxRetries,
+		Queries:      New(connPool),
 	}
+	for _, option := range options {
+		option(store)
+	}
+	return store
+}
+
+// TxOptions configure a transaction run by execTx.
+type TxOptions struct {
+	// Name labels retry metrics.
+	Name     string
+	IsoLevel pgx.TxIsoLevel
+	ReadOnly bool
 }
 
-// ExecTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.connPool.Begin(ctx)
+// execTx executes fn within a database transaction. The whole transaction,
+// fn included, is retried with jittered backoff while it fails with a
+// serialization failure or deadlock, so fn must not have side effects outside
+// of q that cannot be repeated.
+func (store *SQLStore) execTx(ctx context.Context, opts TxOptions, fn func(*Queries) error) (err error) {
+	ctx, span := startSpan(ctx, "execTx")
+	defer func() { endSpan(span, err) }()
+
+	// targets added by a rolled back attempt are not carried into the next
+	record := AuditRecordFrom(ctx)
+	var checkpoint int
+	if record != nil {
+		checkpoint = record.checkpoint()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = store.runTx(ctx, opts, fn)
+
+		errorType, retryable := retryableTxError(err)
+		if !retryable || attempt >= store.maxTxRetries {
+			return err
+		}
+		if record != nil {
+			record.restore(checkpoint)
+		}
+
+		metrics.TxRetried(opts.Name, errorType)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error_type", errorType),
+		))
+		logging.FromContext(ctx).Debug("retrying transaction", "tx", opts.Name, "attempt", attempt+1, "error_type", errorType)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txBackoff(attempt)):
+		}
+	}
+}
+
+// runTx makes one attempt at the transaction.
+func (store *SQLStore) runTx(ctx context.Context, opts TxOptions, fn func(*Queries) error) error {
+	logger := logging.FromContext(ctx)
+	start := time.Now()
+
+	accessMode := pgx.ReadWrite
+	if opts.ReadOnly {
+		accessMode = pgx.ReadOnly
+	}
+
+	tx, err := store.connPool.BeginTx(ctx, pgx.TxOptions{IsoLevel: opts.IsoLevel, AccessMode: accessMode})
 	if err != nil {
 		return err
 	}
 
 	q := New(tx)
 	err = fn(q)
+	if err == nil {
+		err = writeAuditInTx(ctx, q)
+	}
 	if err != nil {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			logger.Error("transaction rollback failed", "error", err, "rollback_error", rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
+		logger.Debug("transaction rolled back", "duration", time.Since(start), "error", err)
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Debug("transaction commit failed", "duration", time.Since(start), "error", err)
 		return err
 	}
+	logger.Debug("transaction committed", "duration", time.Since(start))
 
-	return tx.Commit(ctx)
+	if record := AuditRecordFrom(ctx); record != nil {
+		record.MarkWritten()
+	}
+	return nil
 }
 
 type TransferTxParams struct {
@@ -56,17 +170,33 @@ type TransferTxParams struct {
 
 type TransferTxResult struct {
 	Transfer    Transfer `json:"transfer"`
+	Journal     Journal  `json:"journal"`
 	FromAccount Account  `json:"from_account"`
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
 }
 
-func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
-	var result TransferTxResult
+func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (result TransferTxResult, err error) {
+	start := time.Now()
+	defer func() {
+		errorType := ""
+		if err != nil {
+			errorType = string(apperr.From(err).Code)
+			metrics.TxFailed("transfer", errorType)
+		} else {
+			metrics.TransferCommitted(result.FromAccount.Currency, result.Transfer.Amount)
+		}
+		metrics.ObserveTransferTx(errorType, time.Since(start))
+	}()
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+	err = store.execTx(ctx, TxOptions{Name: "transfer"}, func(q *Queries) error {
+		// the row locks taken here serialize status changes and writers of
+		// each account's entry hash chain
+		err := lockActiveAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
 
 		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
 			FromAccountID: arg.FromAccountID,
@@ -78,39 +208,82 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		// both entries are posted to one journal; the database rejects the
+		// commit unless the journal sums to zero per currency
+		result.Journal, err = q.CreateJournal(ctx, pgtype.Int8{Int64: result.Transfer.ID, Valid: true})
+		if err != nil {
+			return err
+		}
 
+		if arg.FromAccountID < arg.ToAccountID {
+			result.FromAccount, result.ToAccount, err = addSubstractMoney(ctx, q, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+		} else {
+			result.ToAccount, result.FromAccount, err = addSubstractMoney(ctx, q, arg.ToAccountID, arg.FromAccountID, -arg.Amount)
+		}
 		if err != nil {
 			return err
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+		result.FromEntry, err = createChainedEntry(ctx, q, result.Journal.ID, arg.FromAccountID, -arg.Amount)
+		if err != nil {
+			return err
+		}
 
+		result.ToEntry, err = createChainedEntry(ctx, q, result.Journal.ID, arg.ToAccountID, arg.Amount)
 		if err != nil {
 			return err
 		}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addSubstractMoney(ctx, q, arg.FromAccountID, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addSubstractMoney(ctx, q, arg.ToAccountID, arg.FromAccountID, -arg.Amount)
+		if record := AuditRecordFrom(ctx); record != nil {
+			record.AddTarget(AggregateTransfer, result.Transfer.ID)
+			record.AddTarget(AggregateAccount, arg.FromAccountID)
+			record.AddTarget(AggregateAccount, arg.ToAccountID)
+		}
+
+		if err = publishAccountEvent(ctx, q, result.FromAccount, result.FromEntry); err != nil {
+			return err
 		}
+
+		if err = publishAccountEvent(ctx, q, result.ToAccount, result.ToEntry); err != nil {
+			return err
+		}
+
+		_, err = addOutboxEvent(ctx, q, AggregateTransfer, result.Transfer.ID, EventTransferCreated, 1, TransferCreatedV1{
+			TransferID:    result.Transfer.ID,
+			JournalID:     result.Journal.ID,
+			FromAccountID: result.Transfer.FromAccountID,
+			ToAccountID:   result.Transfer.ToAccountID,
+			Amount:        result.Transfer.Amount,
+			Currency:      result.FromAccount.Currency,
+			CreatedAt:     result.Transfer.CreatedAt.Time,
+		})
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return addTransferNotificationJobs(ctx, q, result.Transfer)
 	})
 
 	return result, err
 }
 
+// lockActiveAccounts locks the accounts in id order, so that transfers in
+// opposite directions cannot deadlock, and fails with failed_precondition
+// unless every one of them is active.
+func lockActiveAccounts(ctx context.Context, q *Queries, ids ...int64) error {
+	slices.Sort(ids)
+	for _, id := range ids {
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if account.Status != AccountStatusActive {
+			return apperr.New(apperr.CodeFailedPrecondition, "account %d is %s", account.ID, account.Status)
+		}
+	}
+	return nil
+}
+
 func addSubstractMoney(ctx context.Context, q *Queries, substractFromAccountID int64, addToAccountID int64, amount int64) (fromAccount, toAccount Account, err error) {
 	fromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
 		AccountID: substractFromAccountID,
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -11,7 +12,7 @@ func TestTransferTx(t *testing.T) {
 	store := NewStore(testPool)
 
 	fromAccount := CreateRandomAccount(t)
-	toAccount := CreateRandomAccount(t)
+	toAccount := createRandomAccountWithCurrency(t, fromAccount.Currency)
 	amount := int64(10)
 	fmt.Println(">> before:", fromAccount.Balance, toAccount.Balance)
 
@@ -50,6 +51,14 @@ func TestTransferTx(t *testing.T) {
 		_, err = store.GetTransfer(context.Background(), result.Transfer.ID)
 		require.NoError(t, err)
 
+		//check journal
+		journal, err := store.GetJournalByTransfer(context.Background(), result.Journal.TransferID)
+		require.NoError(t, err)
+		require.Equal(t, result.Journal.ID, journal.ID)
+		require.Equal(t, result.Transfer.ID, journal.TransferID.Int64)
+		require.Equal(t, journal.ID, result.FromEntry.JournalID)
+		require.Equal(t, journal.ID, result.ToEntry.JournalID)
+
 		//check from entry
 		_, err = store.GetEntry(context.Background(), result.FromEntry.ID)
 		require.NoError(t, err)
@@ -121,7 +130,7 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testPool)
 	acc1 := CreateRandomAccount(t)
-	acc2 := CreateRandomAccount(t)
+	acc2 := createRandomAccountWithCurrency(t, acc1.Currency)
 	amount := int64(10)
 
 	n := 10
@@ -159,3 +168,26 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, acc2.Balance, updatedAcc2.Balance)
 }
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	store := NewStore(testPool)
+	fromAccount := CreateRandomAccount(t)
+	toAccount := createRandomAccountWithCurrency(t, fromAccount.Currency)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		ID:     toAccount.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	require.Equal(t, apperr.CodeFailedPrecondition, apperr.From(err).Code)
+
+	updatedFromAccount, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, updatedFromAccount.Balance)
+}
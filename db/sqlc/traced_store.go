@@ -0,0 +1,460 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+
+// tracedStore wraps a Store so that every method call becomes a span.
+type tracedStore struct {
+	store Store
+}
+
+var _ Store = (*tracedStore)(nil)
+
+// NewTracedStore returns store with each method call recorded as a span
+// named "Store.<method>".
+func NewTracedStore(store Store) Store {
+	return &tracedStore{store: store}
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "Store."+name)
+}
+
+// endSpan ends span, marking it failed unless err is nil or pgx.ErrNoRows,
+// which callers treat as a regular outcome.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (store *tracedStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "AddAccountBalance")
+	defer func() { endSpan(span, err) }()
+	return store.store.AddAccountBalance(ctx, arg)
+}
+
+func (store *tracedStore) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) (_ []WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "ClaimDueWebhookDeliveries")
+	defer func() { endSpan(span, err) }()
+	return store.store.ClaimDueWebhookDeliveries(ctx, arg)
+}
+
+func (store *tracedStore) ClaimJobs(ctx context.Context, arg ClaimJobsParams) (_ []Job, err error) {
+	ctx, span := startSpan(ctx, "ClaimJobs")
+	defer func() { endSpan(span, err) }()
+	return store.store.ClaimJobs(ctx, arg)
+}
+
+func (store *tracedStore) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) (_ []OutboxEvent, err error) {
+	ctx, span := startSpan(ctx, "ClaimOutboxEvents")
+	defer func() { endSpan(span, err) }()
+	return store.store.ClaimOutboxEvents(ctx, arg)
+}
+
+func (store *tracedStore) CompleteJob(ctx context.Context, arg CompleteJobParams) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "CompleteJob")
+	defer func() { endSpan(span, err) }()
+	return store.store.CompleteJob(ctx, arg)
+}
+
+func (store *tracedStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "CreateAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateAccount(ctx, arg)
+}
+
+func (store *tracedStore) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (_ AuditLog, err error) {
+	ctx, span := startSpan(ctx, "CreateAuditLog")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateAuditLog(ctx, arg)
+}
+
+func (store *tracedStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (_ Entry, err error) {
+	ctx, span := startSpan(ctx, "CreateEntry")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateEntry(ctx, arg)
+}
+
+func (store *tracedStore) CreateJournal(ctx context.Context, transferID pgtype.Int8) (_ Journal, err error) {
+	ctx, span := startSpan(ctx, "CreateJournal")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateJournal(ctx, transferID)
+}
+
+func (store *tracedStore) CreateNotification(ctx context.Context, arg CreateNotificationParams) (_ Notification, err error) {
+	ctx, span := startSpan(ctx, "CreateNotification")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateNotification(ctx, arg)
+}
+
+func (store *tracedStore) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (_ OutboxEvent, err error) {
+	ctx, span := startSpan(ctx, "CreateOutboxEvent")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateOutboxEvent(ctx, arg)
+}
+
+func (store *tracedStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (_ Transfer, err error) {
+	ctx, span := startSpan(ctx, "CreateTransfer")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateTransfer(ctx, arg)
+}
+
+func (store *tracedStore) CreateUser(ctx context.Context, arg CreateUserParams) (_ User, err error) {
+	ctx, span := startSpan(ctx, "CreateUser")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateUser(ctx, arg)
+}
+
+func (store *tracedStore) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (_ WebhookSubscription, err error) {
+	ctx, span := startSpan(ctx, "CreateWebhookSubscription")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateWebhookSubscription(ctx, arg)
+}
+
+func (store *tracedStore) DeleteAccount(ctx context.Context, id int64) (err error) {
+	ctx, span := startSpan(ctx, "DeleteAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.DeleteAccount(ctx, id)
+}
+
+func (store *tracedStore) DeleteEntry(ctx context.Context, id int64) (err error) {
+	ctx, span := startSpan(ctx, "DeleteEntry")
+	defer func() { endSpan(span, err) }()
+	return store.store.DeleteEntry(ctx, id)
+}
+
+func (store *tracedStore) DeleteStaleRateLimitBuckets(ctx context.Context, updatedAt pgtype.Timestamptz) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "DeleteStaleRateLimitBuckets")
+	defer func() { endSpan(span, err) }()
+	return store.store.DeleteStaleRateLimitBuckets(ctx, updatedAt)
+}
+
+func (store *tracedStore) DeleteTransfer(ctx context.Context, id int64) (err error) {
+	ctx, span := startSpan(ctx, "DeleteTransfer")
+	defer func() { endSpan(span, err) }()
+	return store.store.DeleteTransfer(ctx, id)
+}
+
+func (store *tracedStore) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (_ Job, err error) {
+	ctx, span := startSpan(ctx, "EnqueueJob")
+	defer func() { endSpan(span, err) }()
+	return store.store.EnqueueJob(ctx, arg)
+}
+
+func (store *tracedStore) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (_ []WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "EnqueueWebhookDeliveries")
+	defer func() { endSpan(span, err) }()
+	return store.store.EnqueueWebhookDeliveries(ctx, arg)
+}
+
+func (store *tracedStore) ExtendWebhookDeliveryLease(ctx context.Context, arg ExtendWebhookDeliveryLeaseParams) (_ WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "ExtendWebhookDeliveryLease")
+	defer func() { endSpan(span, err) }()
+	return store.store.ExtendWebhookDeliveryLease(ctx, arg)
+}
+
+func (store *tracedStore) FailJob(ctx context.Context, arg FailJobParams) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "FailJob")
+	defer func() { endSpan(span, err) }()
+	return store.store.FailJob(ctx, arg)
+}
+
+func (store *tracedStore) GetAccount(ctx context.Context, id int64) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "GetAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetAccount(ctx, id)
+}
+
+func (store *tracedStore) GetAccountForUpdate(ctx context.Context, id int64) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "GetAccountForUpdate")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetAccountForUpdate(ctx, id)
+}
+
+func (store *tracedStore) GetEntry(ctx context.Context, id int64) (_ Entry, err error) {
+	ctx, span := startSpan(ctx, "GetEntry")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetEntry(ctx, id)
+}
+
+func (store *tracedStore) GetEntryChainCutoff(ctx context.Context) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "GetEntryChainCutoff")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetEntryChainCutoff(ctx)
+}
+
+func (store *tracedStore) GetJob(ctx context.Context, id int64) (_ Job, err error) {
+	ctx, span := startSpan(ctx, "GetJob")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetJob(ctx, id)
+}
+
+func (store *tracedStore) GetJournal(ctx context.Context, id int64) (_ Journal, err error) {
+	ctx, span := startSpan(ctx, "GetJournal")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetJournal(ctx, id)
+}
+
+func (store *tracedStore) GetJournalByTransfer(ctx context.Context, transferID pgtype.Int8) (_ Journal, err error) {
+	ctx, span := startSpan(ctx, "GetJournalByTransfer")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetJournalByTransfer(ctx, transferID)
+}
+
+func (store *tracedStore) GetLastAccountEntry(ctx context.Context, accountID int64) (_ Entry, err error) {
+	ctx, span := startSpan(ctx, "GetLastAccountEntry")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetLastAccountEntry(ctx, accountID)
+}
+
+func (store *tracedStore) GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (_ NotificationPreference, err error) {
+	ctx, span := startSpan(ctx, "GetNotificationPreference")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetNotificationPreference(ctx, arg)
+}
+
+func (store *tracedStore) GetTransfer(ctx context.Context, id int64) (_ Transfer, err error) {
+	ctx, span := startSpan(ctx, "GetTransfer")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetTransfer(ctx, id)
+}
+
+func (store *tracedStore) GetUser(ctx context.Context, username string) (_ User, err error) {
+	ctx, span := startSpan(ctx, "GetUser")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetUser(ctx, username)
+}
+
+func (store *tracedStore) GetWebhookSubscription(ctx context.Context, id int64) (_ WebhookSubscription, err error) {
+	ctx, span := startSpan(ctx, "GetWebhookSubscription")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetWebhookSubscription(ctx, id)
+}
+
+func (store *tracedStore) ListAccounts(ctx context.Context, arg ListAccountsParams) (_ []Account, err error) {
+	ctx, span := startSpan(ctx, "ListAccounts")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListAccounts(ctx, arg)
+}
+
+func (store *tracedStore) ListAuditLogByTarget(ctx context.Context, arg ListAuditLogByTargetParams) (_ []AuditLog, err error) {
+	ctx, span := startSpan(ctx, "ListAuditLogByTarget")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListAuditLogByTarget(ctx, arg)
+}
+
+func (store *tracedStore) ListEntries(ctx context.Context, arg ListEntriesParams) (_ []Entry, err error) {
+	ctx, span := startSpan(ctx, "ListEntries")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListEntries(ctx, arg)
+}
+
+func (store *tracedStore) ListEntriesByAccount(ctx context.Context, accountID int64) (_ []Entry, err error) {
+	ctx, span := startSpan(ctx, "ListEntriesByAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListEntriesByAccount(ctx, accountID)
+}
+
+func (store *tracedStore) ListEntriesByAccountAfter(ctx context.Context, arg ListEntriesByAccountAfterParams) (_ []Entry, err error) {
+	ctx, span := startSpan(ctx, "ListEntriesByAccountAfter")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListEntriesByAccountAfter(ctx, arg)
+}
+
+func (store *tracedStore) ListEntriesByJournal(ctx context.Context, journalID int64) (_ []Entry, err error) {
+	ctx, span := startSpan(ctx, "ListEntriesByJournal")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListEntriesByJournal(ctx, journalID)
+}
+
+func (store *tracedStore) ListJobsByStatus(ctx context.Context, arg ListJobsByStatusParams) (_ []Job, err error) {
+	ctx, span := startSpan(ctx, "ListJobsByStatus")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListJobsByStatus(ctx, arg)
+}
+
+func (store *tracedStore) ListNotificationPreferences(ctx context.Context, username string) (_ []NotificationPreference, err error) {
+	ctx, span := startSpan(ctx, "ListNotificationPreferences")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListNotificationPreferences(ctx, username)
+}
+
+func (store *tracedStore) ListNotifications(ctx context.Context, arg ListNotificationsParams) (_ []Notification, err error) {
+	ctx, span := startSpan(ctx, "ListNotifications")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListNotifications(ctx, arg)
+}
+
+func (store *tracedStore) ListTransfers(ctx context.Context, arg ListTransfersParams) (_ []Transfer, err error) {
+	ctx, span := startSpan(ctx, "ListTransfers")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListTransfers(ctx, arg)
+}
+
+func (store *tracedStore) ListTransfersBetweenAccounts(ctx context.Context, arg ListTransfersBetweenAccountsParams) (_ []Transfer, err error) {
+	ctx, span := startSpan(ctx, "ListTransfersBetweenAccounts")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListTransfersBetweenAccounts(ctx, arg)
+}
+
+func (store *tracedStore) ListTransfersByAccount(ctx context.Context, fromAccountID int64) (_ []Transfer, err error) {
+	ctx, span := startSpan(ctx, "ListTransfersByAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListTransfersByAccount(ctx, fromAccountID)
+}
+
+func (store *tracedStore) ListUnbalancedJournals(ctx context.Context) (_ []ListUnbalancedJournalsRow, err error) {
+	ctx, span := startSpan(ctx, "ListUnbalancedJournals")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListUnbalancedJournals(ctx)
+}
+
+func (store *tracedStore) ListUsers(ctx context.Context, arg ListUsersParams) (_ []User, err error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListUsers(ctx, arg)
+}
+
+func (store *tracedStore) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) (_ []WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "ListWebhookDeliveries")
+	defer func() { endSpan(span, err) }()
+	return store.store.ListWebhookDeliveries(ctx, arg)
+}
+
+func (store *tracedStore) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (_ Notification, err error) {
+	ctx, span := startSpan(ctx, "MarkNotificationRead")
+	defer func() { endSpan(span, err) }()
+	return store.store.MarkNotificationRead(ctx, arg)
+}
+
+func (store *tracedStore) MarkOutboxEventPublished(ctx context.Context, id int64) (err error) {
+	ctx, span := startSpan(ctx, "MarkOutboxEventPublished")
+	defer func() { endSpan(span, err) }()
+	return store.store.MarkOutboxEventPublished(ctx, id)
+}
+
+func (store *tracedStore) NotifyAccountEvent(ctx context.Context, payload string) (err error) {
+	ctx, span := startSpan(ctx, "NotifyAccountEvent")
+	defer func() { endSpan(span, err) }()
+	return store.store.NotifyAccountEvent(ctx, payload)
+}
+
+func (store *tracedStore) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (_ WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "RecordWebhookDeliveryAttempt")
+	defer func() { endSpan(span, err) }()
+	return store.store.RecordWebhookDeliveryAttempt(ctx, arg)
+}
+
+func (store *tracedStore) ReleaseOutboxEvents(ctx context.Context, ids []int64) (err error) {
+	ctx, span := startSpan(ctx, "ReleaseOutboxEvents")
+	defer func() { endSpan(span, err) }()
+	return store.store.ReleaseOutboxEvents(ctx, ids)
+}
+
+func (store *tracedStore) RetryJob(ctx context.Context, arg RetryJobParams) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "RetryJob")
+	defer func() { endSpan(span, err) }()
+	return store.store.RetryJob(ctx, arg)
+}
+
+func (store *tracedStore) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (_ TakeRateLimitTokenRow, err error) {
+	ctx, span := startSpan(ctx, "TakeRateLimitToken")
+	defer func() { endSpan(span, err) }()
+	return store.store.TakeRateLimitToken(ctx, arg)
+}
+
+func (store *tracedStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "UpdateAccount")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpdateAccount(ctx, arg)
+}
+
+func (store *tracedStore) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "UpdateAccountStatus")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpdateAccountStatus(ctx, arg)
+}
+
+func (store *tracedStore) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (_ Entry, err error) {
+	ctx, span := startSpan(ctx, "UpdateEntry")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpdateEntry(ctx, arg)
+}
+
+func (store *tracedStore) UpdateTransferAmount(ctx context.Context, arg UpdateTransferAmountParams) (err error) {
+	ctx, span := startSpan(ctx, "UpdateTransferAmount")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpdateTransferAmount(ctx, arg)
+}
+
+func (store *tracedStore) UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (_ NotificationPreference, err error) {
+	ctx, span := startSpan(ctx, "UpsertNotificationPreference")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpsertNotificationPreference(ctx, arg)
+}
+
+func (store *tracedStore) TransferTx(ctx context.Context, arg TransferTxParams) (_ TransferTxResult, err error) {
+	ctx, span := startSpan(ctx, "TransferTx")
+	defer func() { endSpan(span, err) }()
+	return store.store.TransferTx(ctx, arg)
+}
+
+func (store *tracedStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "CreateAccountTx")
+	defer func() { endSpan(span, err) }()
+	return store.store.CreateAccountTx(ctx, arg)
+}
+
+func (store *tracedStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (_ Account, err error) {
+	ctx, span := startSpan(ctx, "UpdateAccountStatusTx")
+	defer func() { endSpan(span, err) }()
+	return store.store.UpdateAccountStatusTx(ctx, arg)
+}
+
+func (store *tracedStore) ProcessOutbox(ctx context.Context, limit int32, publish func(context.Context, OutboxEvent) error) (_ int, err error) {
+	ctx, span := startSpan(ctx, "ProcessOutbox")
+	defer func() { endSpan(span, err) }()
+	return store.store.ProcessOutbox(ctx, limit, publish)
+}
+
+// ListenAccountEvents is not traced: it runs for the lifetime of the process.
+func (store *tracedStore) ListenAccountEvents(ctx context.Context, handle func(AccountEvent)) error {
+	return store.store.ListenAccountEvents(ctx, handle)
+}
+
+func (store *tracedStore) WriteAuditRecord(ctx context.Context, record *AuditRecord, statusCode int) (err error) {
+	ctx, span := startSpan(ctx, "WriteAuditRecord")
+	defer func() { endSpan(span, err) }()
+	return store.store.WriteAuditRecord(ctx, record, statusCode)
+}
+
+func (store *tracedStore) VerifyAccountChain(ctx context.Context, accountID int64) (_ ChainVerification, err error) {
+	ctx, span := startSpan(ctx, "VerifyAccountChain")
+	defer func() { endSpan(span, err) }()
+	return store.store.VerifyAccountChain(ctx, accountID)
+}
+
+func (store *tracedStore) Ping(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "Ping")
+	defer func() { endSpan(span, err) }()
+	return store.store.Ping(ctx)
+}
+
+func (store *tracedStore) GetSchemaMigration(ctx context.Context) (_ SchemaMigration, err error) {
+	ctx, span := startSpan(ctx, "GetSchemaMigration")
+	defer func() { endSpan(span, err) }()
+	return store.store.GetSchemaMigration(ctx)
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubStore answers GetAccount with err; other methods are not implemented.
+type stubStore struct {
+	Store
+	err error
+}
+
+func (store stubStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return Account{ID: id}, store.err
+}
+
+func TestTracedStore(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	testCases := []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{name: "OK", status: codes.Unset},
+		{name: "NoRows", err: pgx.ErrNoRows, status: codes.Unset},
+		{name: "Error", err: errors.New("boom"), status: codes.Error},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+			account, err := NewTracedStore(stubStore{err: tc.err}).GetAccount(context.Background(), 7)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, int64(7), account.ID)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			require.Equal(t, "Store.GetAccount", spans[0].Name())
+			require.Equal(t, tc.status, spans[0].Status().Code)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer records a span per query and logs queries slower than
+// SlowThreshold at warn level and failed queries at debug level, using the
+// logger of the query context so records carry the request ID. Query
+// arguments are never logged or attached to spans.
+type QueryTracer struct {
+	SlowThreshold time.Duration
+}
+
+var _ pgx.QueryTracer = (*QueryTracer)(nil)
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql   string
+	start time.Time
+	span  trace.Span
+}
+
+func (tracer *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{sql: data.SQL, start: time.Now(), span: span})
+}
+
+func (tracer *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	query, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+	duration := time.Since(query.start)
+	logger := logging.FromContext(ctx)
+
+	query.span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	endSpan(query.span, data.Err)
+
+	if data.Err != nil && data.Err != pgx.ErrNoRows {
+		logger.Debug("query failed", "query", queryName(query.sql), "duration", duration, "error", data.Err)
+	}
+	if tracer.SlowThreshold > 0 && duration >= tracer.SlowThreshold {
+		logger.Warn("slow query", "query", queryName(query.sql), "duration", duration, "rows", data.CommandTag.RowsAffected())
+	}
+}
+
+// queryName returns the sqlc query name from its "-- name: X :kind" header,
+// or the first line of hand-written SQL.
+func queryName(sql string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(sql), "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return line
+}
@@ -0,0 +1,11 @@
+// Package docs embeds the generated OpenAPI document and the Swagger UI page
+// that renders it. Regenerate the document with `make openapi`.
+package docs
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
+
+//go:embed index.html
+var SwaggerUI []byte
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+)
+
+const (
+	defaultBatchSize = 100
+	defaultInterval  = time.Second
+	// stallTimeout is how long a batch may take before the relay is reported
+	// as stalled.
+	stallTimeout = time.Minute
+)
+
+// Relay moves events from the outbox table to a Publisher.
+type Relay struct {
+	store     db.Store
+	publisher Publisher
+	interval  time.Duration
+	batchSize int32
+	heartbeat *health.Heartbeat
+}
+
+func NewRelay(store db.Store, publisher Publisher, interval time.Duration) *Relay {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: defaultBatchSize,
+		heartbeat: health.NewHeartbeat(interval + stallTimeout),
+	}
+}
+
+// Run polls the outbox until ctx is cancelled. A full batch is followed
+// immediately by the next one so that a backlog drains without waiting.
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := relay.RelayBatch(ctx)
+		if ctx.Err() == nil {
+			relay.heartbeat.Beat(err)
+		}
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("outbox relay failed", "error", err)
+		}
+
+		if err == nil && published == int(relay.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports whether the relay is running and its last batch succeeded.
+func (relay *Relay) Check(ctx context.Context) error {
+	return relay.heartbeat.Check(ctx)
+}
+
+// RelayBatch publishes one batch of pending events and returns how many were published.
+func (relay *Relay) RelayBatch(ctx context.Context) (int, error) {
+	return relay.store.ProcessOutbox(ctx, relay.batchSize, func(ctx context.Context, event db.OutboxEvent) error {
+		return relay.publisher.Publish(ctx, fromOutbox(event))
+	})
+}
+
+func fromOutbox(event db.OutboxEvent) Event {
+	return Event{
+		ID:            event.ID,
+		Type:          event.EventType,
+		Version:       event.Version,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Payload,
+		OccurredAt:    event.CreatedAt.Time,
+	}
+}
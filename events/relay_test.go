@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, Event) error {
+	return errors.New("broker unavailable")
+}
+
+func TestRelayBatch(t *testing.T) {
+	outbox := []db.OutboxEvent{
+		{
+			ID:            1,
+			AggregateType: db.AggregateAccount,
+			AggregateID:   7,
+			EventType:     db.EventAccountCreated,
+			Version:       1,
+			Payload:       []byte(`{"account_id":7}`),
+			CreatedAt:     pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+		},
+		{
+			ID:            2,
+			AggregateType: db.AggregateTransfer,
+			AggregateID:   3,
+			EventType:     db.EventTransferCreated,
+			Version:       1,
+			Payload:       []byte(`{"transfer_id":3}`),
+			CreatedAt:     pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+		},
+	}
+
+	processOutbox := func(ctx context.Context, limit int32, publish func(context.Context, db.OutboxEvent) error) (int, error) {
+		for i, event := range outbox {
+			if err := publish(ctx, event); err != nil {
+				return i, err
+			}
+		}
+		return len(outbox), nil
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().ProcessOutbox(gomock.Any(), gomock.Eq(int32(defaultBatchSize)), gomock.Any()).Times(1).DoAndReturn(processOutbox)
+
+		publisher := NewMemoryPublisher()
+		published, err := NewRelay(store, publisher, time.Second).RelayBatch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, published)
+
+		got := publisher.Events()
+		require.Len(t, got, 2)
+		for i, event := range got {
+			require.Equal(t, outbox[i].ID, event.ID)
+			require.Equal(t, outbox[i].EventType, event.Type)
+			require.Equal(t, outbox[i].Version, event.Version)
+			require.Equal(t, outbox[i].AggregateID, event.AggregateID)
+			require.JSONEq(t, string(outbox[i].Payload), string(event.Payload))
+		}
+	})
+
+	t.Run("PublishError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().ProcessOutbox(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(processOutbox)
+
+		published, err := NewRelay(store, failingPublisher{}, time.Second).RelayBatch(context.Background())
+		require.Error(t, err)
+		require.Zero(t, published)
+	})
+}
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	if req.GetOwner() == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner is required")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported currency %q", req.GetCurrency())
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:    req.GetOwner(),
+		Currency: req.GetCurrency(),
+		Balance:  0,
+	})
+	if err != nil {
+		return nil, apperr.From(err)
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "id must be positive")
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, apperr.From(apperr.NotFoundAs(err, "account [%d]", req.GetId()))
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if req.GetLimit() < 5 || req.GetLimit() > 10 {
+		return nil, status.Error(codes.InvalidArgument, "limit must be between 5 and 10")
+	}
+	if req.GetPage() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "page must be positive")
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Limit:  req.GetLimit(),
+		Offset: (req.GetPage() - 1) * req.GetLimit(),
+	})
+	if err != nil {
+		return nil, apperr.From(err)
+	}
+
+	rsp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, 0, len(accounts))}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+	return rsp, nil
+}
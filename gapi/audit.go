@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	auditMethod        = "GRPC"
+	anonymousActor     = "anonymous"
+	requestIDHeader    = "x-request-id"
+	forwardedForHeader = "x-forwarded-for"
+)
+
+// auditLog is the gRPC counterpart of the HTTP audit middleware: every
+// state-changing RPC gets an audit record, written by the store transaction
+// when there is one and after the handler otherwise.
+func (server *Server) auditLog(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !isStateChanging(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	record := &db.AuditRecord{
+		Actor:     anonymousActor,
+		Method:    auditMethod,
+		Route:     info.FullMethod,
+		RequestID: logging.RequestID(ctx),
+		ClientIP:  server.clientIP(ctx, md),
+	}
+	if msg, ok := req.(proto.Message); ok {
+		marshaler := protojson.MarshalOptions{UseProtoNames: true}
+		if body, err := marshaler.Marshal(msg); err == nil {
+			record.RequestBody = util.RedactJSON(body)
+		}
+	}
+
+	rsp, err := handler(db.WithAuditRecord(ctx, record), req)
+
+	if !record.Written() {
+		statusCode := runtime.HTTPStatusFromCode(status.Code(err))
+		if auditErr := server.store.WriteAuditRecord(context.WithoutCancel(ctx), record, statusCode); auditErr != nil {
+			logging.FromContext(ctx).Error("failed to write audit record", "error", auditErr)
+		}
+	}
+	return rsp, err
+}
+
+func isStateChanging(fullMethod string) bool {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, prefix := range []string{"Create", "Update", "Delete"} {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuditLogInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	server := NewServer(store)
+
+	var record *db.AuditRecord
+	store.EXPECT().
+		WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Eq(http.StatusConflict)).
+		Times(1).
+		DoAndReturn(func(_ context.Context, r *db.AuditRecord, _ int) error {
+			record = r
+			return nil
+		})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, "req-1", forwardedForHeader, "10.0.0.1, 10.0.0.2", gatewayHeader, server.gatewayToken))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}})
+	req := &pb.CreateUserRequest{Username: "alice", Password: "secret123"}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.UserService/CreateUser"}
+
+	// the request ID reaches the audit record through the logging interceptor
+	_, err := logRequests(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+		return server.auditLog(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			require.NotNil(t, db.AuditRecordFrom(ctx))
+			return nil, status.Error(codes.AlreadyExists, "exists")
+		})
+	})
+	require.Error(t, err)
+
+	require.NotNil(t, record)
+	require.Equal(t, "req-1", record.RequestID)
+	require.Equal(t, "10.0.0.2", record.ClientIP)
+	require.Equal(t, info.FullMethod, record.Route)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(record.RequestBody, &body))
+	require.Equal(t, "alice", body["username"])
+	require.Equal(t, "[REDACTED]", body["password"])
+}
+
+func TestAuditLogInterceptorSkipsReads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().WriteAuditRecord(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.AccountService/GetAccount"}
+	_, err := NewServer(store).auditLog(context.Background(), &pb.GetAccountRequest{Id: 1}, info, func(ctx context.Context, req any) (any, error) {
+		require.Nil(t, db.AuditRecordFrom(ctx))
+		return &pb.GetAccountResponse{}, nil
+	})
+	require.NoError(t, err)
+}
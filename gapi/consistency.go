@@ -0,0 +1,23 @@
+package gapi
+
+import (
+	"context"
+	"strconv"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const readYourWritesHeader = "x-read-your-writes"
+
+// readYourWrites sends the reads of calls with "x-read-your-writes: true"
+// metadata to the primary, so a client sees its own recent writes even while
+// the replicas lag behind.
+func readYourWrites(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if primary, _ := strconv.ParseBool(firstValue(md, readYourWritesHeader)); primary {
+		ctx = db.WithPrimaryReads(ctx)
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,31 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.AccountService/GetAccount"}
+	primary := func(ctx context.Context, req any) (any, error) {
+		return db.PrimaryReads(ctx), nil
+	}
+
+	rsp, err := readYourWrites(context.Background(), nil, info, primary)
+	require.NoError(t, err)
+	require.Equal(t, false, rsp)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(readYourWritesHeader, "true"))
+	rsp, err = readYourWrites(ctx, nil, info, primary)
+	require.NoError(t, err)
+	require.Equal(t, true, rsp)
+
+	name, ok := gatewayHeaderMatcher("X-Read-Your-Writes")
+	require.True(t, ok)
+	require.Equal(t, readYourWritesHeader, name)
+}
@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:        account.ID,
+		Owner:     account.Owner,
+		Balance:   account.Balance,
+		Currency:  account.Currency,
+		Status:    account.Status,
+		CreatedAt: timestamppb.New(account.CreatedAt.Time),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt.Time),
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		JournalId: entry.JournalID,
+		CreatedAt: timestamppb.New(entry.CreatedAt.Time),
+	}
+}
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt.Time),
+		CreatedAt:         timestamppb.New(user.CreatedAt.Time),
+	}
+}
+
+// convertPublicUser leaves out the personal details of the user, for callers
+// other than the user itself.
+func convertPublicUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:  user.Username,
+		CreatedAt: timestamppb.New(user.CreatedAt.Time),
+	}
+}
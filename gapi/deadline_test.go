@@ -0,0 +1,35 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestDeadline(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.AccountService/GetAccount"}
+	remaining := func(ctx context.Context, req any) (any, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return time.Duration(0), nil
+		}
+		return time.Until(deadline), nil
+	}
+
+	rsp, err := deadline(time.Second)(context.Background(), nil, info, remaining)
+	require.NoError(t, err)
+	require.InDelta(t, time.Second, rsp, float64(100*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	rsp, err = deadline(time.Second)(ctx, nil, info, remaining)
+	require.NoError(t, err)
+	require.LessOrEqual(t, rsp, 100*time.Millisecond, "shorter client deadlines are kept")
+
+	rsp, err = deadline(0)(context.Background(), nil, info, remaining)
+	require.NoError(t, err)
+	require.Zero(t, rsp)
+}
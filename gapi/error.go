@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// logInternalErrors logs the cause of internal errors, which is never sent
+// to the client.
+func logInternalErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	rsp, err := handler(ctx, req)
+
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) && appErr.Code == apperr.CodeInternal {
+		logging.FromContext(ctx).Error("internal error", "method", info.FullMethod, "error", err)
+	}
+	return rsp, err
+}
+
+// writeProblem renders gateway errors as RFC 7807 problems, the same body
+// the HTTP API returns.
+func writeProblem(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	// headers set by the handler, such as Retry-After, apply to errors too
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			if name, ok := gatewayOutgoingHeaderMatcher(key); ok {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}
+		}
+	}
+
+	st, _ := status.FromError(err)
+	renderProblem(ctx, w, r, apperr.FromStatus(st))
+}
+
+func renderProblem(ctx context.Context, w http.ResponseWriter, r *http.Request, appErr *apperr.Error) {
+	w.Header().Set("Content-Type", apperr.ProblemContentType)
+	w.WriteHeader(appErr.HTTPStatus())
+	if err := json.NewEncoder(w).Encode(appErr.Problem(r.URL.Path)); err != nil {
+		logging.FromContext(ctx).Error("failed to write problem", "error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLogRequestsRequestID(t *testing.T) {
+	testCases := []struct {
+		name       string
+		requestID  string
+		propagated bool
+	}{
+		{name: "Propagated", requestID: "req-1", propagated: true},
+		{name: "Assigned"},
+		{name: "TooLong", requestID: strings.Repeat("a", 65)},
+		{name: "InvalidCharacters", requestID: "req-1\nlevel=ERROR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tc.requestID != "" {
+				md.Set(requestIDHeader, tc.requestID)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/pb.UserService/CreateUser"}
+
+			var got string
+			_, err := logRequests(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				got = logging.RequestID(ctx)
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			require.NotEmpty(t, got)
+			if tc.propagated {
+				require.Equal(t, tc.requestID, got)
+			} else {
+				require.NotEqual(t, tc.requestID, got)
+			}
+		})
+	}
+}
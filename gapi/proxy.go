@@ -0,0 +1,95 @@
+package gapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// gatewayHeader carries a token only the gateway of this process knows, which
+// tells its calls apart from those of other clients on loopback.
+const gatewayHeader = "x-gateway-token"
+
+// UseTrustedProxies sets the IPs and CIDRs of proxies whose x-forwarded-for
+// entries are believed when telling the client IP, as for the HTTP API. By
+// default none are; the gateway's own entry is always believed.
+func (server *Server) UseTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return fmt.Errorf("trusted proxy %q is not an IP or CIDR", proxy)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	server.trustedProxies = prefixes
+	return nil
+}
+
+// clientIP returns the address of the peer. When the peer is the gateway or a
+// trusted proxy, it walks x-forwarded-for back from the last entry, appended
+// by the peer, to the first one not appended by a trusted proxy; entries
+// before it come from the client and are ignored.
+func (server *Server) clientIP(ctx context.Context, md metadata.MD) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+
+	ip := addrPort.Addr().Unmap()
+	if !server.fromGateway(md) && !server.trusted(ip) {
+		return ip.String()
+	}
+
+	var forwarded []string
+	for _, value := range md.Get(forwardedForHeader) {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		ip = addr.Unmap()
+		if !server.trusted(ip) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func (server *Server) fromGateway(md metadata.MD) bool {
+	for _, token := range md.Get(gatewayHeader) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(server.gatewayToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) trusted(ip netip.Addr) bool {
+	for _, prefix := range server.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func newGatewayToken() string {
+	return rand.Text()
+}
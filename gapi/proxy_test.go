@@ -0,0 +1,77 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIP(t *testing.T) {
+	server := NewServer(nil)
+	require.NoError(t, server.UseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::1"}))
+
+	loopback := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+	remote := &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7), Port: 40000}
+	proxy := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 3), Port: 40000}
+
+	testCases := []struct {
+		name string
+		addr net.Addr
+		md   metadata.MD
+		want string
+	}{
+		{name: "Peer", addr: remote, want: "203.0.113.7"},
+		{name: "SpoofedForwardedFor", addr: remote, md: metadata.Pairs(forwardedForHeader, "10.0.0.1"), want: "203.0.113.7"},
+		{name: "LocalProxy", addr: loopback, md: metadata.Pairs(forwardedForHeader, "198.51.100.4"), want: "127.0.0.1"},
+		{
+			name: "Gateway",
+			addr: loopback,
+			md:   metadata.Pairs(forwardedForHeader, "10.0.0.1, 198.51.100.4", gatewayHeader, server.gatewayToken),
+			want: "198.51.100.4",
+		},
+		{
+			name: "GatewayBehindTrustedProxy",
+			addr: loopback,
+			md:   metadata.Pairs(forwardedForHeader, "192.0.2.1, 198.51.100.4, 10.0.0.2", gatewayHeader, server.gatewayToken),
+			want: "198.51.100.4",
+		},
+		{
+			name: "SpoofedGatewayToken",
+			addr: loopback,
+			md:   metadata.Pairs(forwardedForHeader, "198.51.100.4", gatewayHeader, "guess"),
+			want: "127.0.0.1",
+		},
+		{name: "TrustedProxy", addr: proxy, md: metadata.Pairs(forwardedForHeader, "192.0.2.1", forwardedForHeader, "198.51.100.4"), want: "198.51.100.4"},
+		{name: "OnlyProxies", addr: proxy, md: metadata.Pairs(forwardedForHeader, "10.0.0.9"), want: "10.0.0.9"},
+		{name: "InvalidEntry", addr: proxy, md: metadata.Pairs(forwardedForHeader, "unknown"), want: "10.0.0.3"},
+		{name: "NoPeer", md: metadata.Pairs(forwardedForHeader, "10.0.0.1")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.addr != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: tc.addr})
+			}
+			require.Equal(t, tc.want, server.clientIP(ctx, tc.md))
+		})
+	}
+}
+
+func TestUseTrustedProxies(t *testing.T) {
+	server := NewServer(nil)
+	require.NoError(t, server.UseTrustedProxies(nil))
+	require.Empty(t, server.trustedProxies)
+	require.Error(t, server.UseTrustedProxies([]string{"proxy.internal"}))
+}
+
+func TestGatewayTokenNotForwarded(t *testing.T) {
+	for _, header := range []string{"X-Gateway-Token", "Grpc-Metadata-X-Gateway-Token"} {
+		_, ok := gatewayHeaderMatcher(header)
+		require.False(t, ok, header)
+	}
+}
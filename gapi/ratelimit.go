@@ -0,0 +1,55 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UseRateLimiter limits calls per method class and client IP. Without a
+// limiter calls are not limited.
+func (server *Server) UseRateLimiter(limiter *ratelimit.Limiter) {
+	server.limiter = limiter
+}
+
+// rateLimit rejects callers over the limit of the method's class with
+// RESOURCE_EXHAUSTED, reporting the limit in the response headers.
+func (server *Server) rateLimit(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if server.limiter == nil {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	result := server.limiter.Allow(ctx, methodClass(info.FullMethod), "ip:"+server.clientIP(ctx, md))
+
+	header := metadata.MD{}
+	for key, values := range result.Header() {
+		header.Set(key, values...)
+	}
+	if len(header) > 0 {
+		_ = grpc.SetHeader(ctx, header)
+	}
+	if !result.Allowed {
+		return nil, apperr.New(apperr.CodeRateLimited, "rate limit exceeded")
+	}
+
+	return handler(ctx, req)
+}
+
+func methodClass(fullMethod string) ratelimit.Class {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	switch {
+	case method == "CreateUser":
+		return ratelimit.ClassLogin
+	case method == "CreateTransfer":
+		return ratelimit.ClassTransfers
+	case strings.HasPrefix(method, "Get"), strings.HasPrefix(method, "List"):
+		return ratelimit.ClassReads
+	default:
+		return ratelimit.ClassWrites
+	}
+}
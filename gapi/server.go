@@ -0,0 +1,214 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/avfirsov/golang-backend-masterclass/ratelimit"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/soheilhy/cmux"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Server serves the gRPC API over the same store as the HTTP API.
+type Server struct {
+	pb.UnimplementedAccountServiceServer
+	pb.UnimplementedTransferServiceServer
+	pb.UnimplementedUserServiceServer
+	store          db.Store
+	limiter        *ratelimit.Limiter
+	trustedProxies []netip.Prefix
+	gatewayToken   string
+
+	mu          sync.Mutex
+	stopping    bool
+	grpcServer  *grpc.Server
+	httpServer  *http.Server
+	gatewayConn *grpc.ClientConn
+}
+
+func NewServer(store db.Store) *Server {
+	return &Server{store: store, gatewayToken: newGatewayToken()}
+}
+
+// Start serves gRPC and its JSON gateway on config.GRPCServerAddress until
+// Shutdown is called, after which it returns nil. Connections are told apart
+// by their first bytes; gateway calls are forwarded to the gRPC server so both
+// go through the same interceptors.
+func (server *Server) Start(config util.Config) error {
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logRequests, server.rateLimit, logInternalErrors, server.auditLog, deadline(config.RequestTimeout), readYourWrites),
+	)
+	pb.RegisterAccountServiceServer(grpcServer, server)
+	pb.RegisterTransferServiceServer(grpcServer, server)
+	pb.RegisterUserServiceServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	gateway, conn, err := newGateway(context.Background(), config.GRPCServerAddress, server.gatewayToken)
+	if err != nil {
+		return err
+	}
+	if config.HTTPMaxBodyBytes > 0 {
+		gateway = limitBody(gateway, config.HTTPMaxBodyBytes)
+	}
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	mux := cmux.New(listener)
+	mux.SetReadTimeout(config.HTTPReadHeaderTimeout)
+	grpcListener := mux.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+	httpListener := mux.Match(cmux.Any())
+
+	httpServer := &http.Server{
+		Handler:           gateway,
+		ReadTimeout:       config.HTTPReadTimeout,
+		ReadHeaderTimeout: config.HTTPReadHeaderTimeout,
+		WriteTimeout:      config.HTTPWriteTimeout,
+		IdleTimeout:       config.HTTPIdleTimeout,
+		MaxHeaderBytes:    config.HTTPMaxHeaderBytes,
+	}
+
+	server.mu.Lock()
+	server.grpcServer = grpcServer
+	server.httpServer = httpServer
+	server.gatewayConn = conn
+	server.mu.Unlock()
+
+	errs := make(chan error, 3)
+	go func() { errs <- grpcServer.Serve(grpcListener) }()
+	go func() { errs <- httpServer.Serve(httpListener) }()
+	go func() { errs <- mux.Serve() }()
+	err = <-errs
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.stopping {
+		return nil
+	}
+	return err
+}
+
+// Shutdown drains gateway requests, then waits for in-flight RPCs to finish.
+// When ctx expires first the remaining RPCs are cancelled.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.mu.Lock()
+	server.stopping = true
+	grpcServer, httpServer, conn := server.grpcServer, server.httpServer, server.gatewayConn
+	server.mu.Unlock()
+
+	if grpcServer == nil {
+		return nil
+	}
+
+	// gateway requests are RPCs on this server, so they drain first
+	err := httpServer.Shutdown(ctx)
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		err = errors.Join(err, ctx.Err())
+	}
+
+	return errors.Join(err, conn.Close())
+}
+
+// limitBody caps request bodies at limit bytes. The gateway reports body
+// read errors as plain invalid arguments, so declared lengths over the limit
+// are rejected here with the proper status.
+func limitBody(next http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			err := &http.MaxBytesError{Limit: limit}
+			renderProblem(r.Context(), w, r, apperr.Invalid(err))
+			return
+		}
+		http.MaxBytesHandler(next, limit).ServeHTTP(w, r)
+	})
+}
+
+func newGateway(ctx context.Context, address, token string) (http.Handler, *grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(func(context.Context, *http.Request) metadata.MD {
+			return metadata.Pairs(gatewayHeader, token)
+		}),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+		runtime.WithErrorHandler(writeProblem),
+	)
+
+	for _, register := range []func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error{
+		pb.RegisterAccountServiceHandler,
+		pb.RegisterTransferServiceHandler,
+		pb.RegisterUserServiceHandler,
+	} {
+		if err := register(ctx, mux, conn); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return mux, conn, nil
+}
+
+// gatewayHeaderMatcher forwards the request ID, the read-your-writes flag
+// and W3C trace context alongside the default headers. Clients cannot pass
+// the gateway token.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	switch key = strings.ToLower(key); key {
+	case requestIDHeader, readYourWritesHeader, "traceparent", "tracestate", "baggage":
+		return key, true
+	case gatewayHeader, strings.ToLower(runtime.MetadataHeaderPrefix) + gatewayHeader:
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns the request ID and rate limit headers
+// under their HTTP names and other metadata with the Grpc-Metadata- prefix.
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	switch {
+	case key == requestIDHeader:
+		return "X-Request-ID", true
+	case key == "retry-after", strings.HasPrefix(key, "ratelimit-"):
+		return http.CanonicalHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
@@ -0,0 +1,69 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServerStartShutdown(t *testing.T) {
+	account := randAccount(util.USD)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(2).Return(account, nil)
+
+	config := util.Config{
+		GRPCServerAddress:     freeAddress(t),
+		HTTPReadHeaderTimeout: time.Second,
+		HTTPMaxBodyBytes:      16,
+	}
+	server := NewServer(store)
+	started := make(chan error, 1)
+	go func() { started <- server.Start(config) }()
+
+	conn, err := grpc.NewClient(config.GRPCServerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// gRPC and the gateway share the port
+	require.Eventually(t, func() bool {
+		_, err := pb.NewAccountServiceClient(conn).GetAccount(context.Background(), &pb.GetAccountRequest{Id: account.ID})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	baseURL := "http://" + config.GRPCServerAddress
+	rsp, err := http.Get(fmt.Sprintf("%s/v1/accounts/%d", baseURL, account.ID))
+	require.NoError(t, err)
+	rsp.Body.Close()
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	rsp, err = http.Post(baseURL+"/v1/accounts", "application/json", strings.NewReader(`{"owner": "a very long owner name", "currency": "USD"}`))
+	require.NoError(t, err)
+	rsp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, rsp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+	require.NoError(t, <-started)
+}
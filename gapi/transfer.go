@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/avfirsov/golang-backend-masterclass/apperr"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if req.GetFromAccountId() < 1 || req.GetToAccountId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "account ids must be positive")
+	}
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported currency %q", req.GetCurrency())
+	}
+
+	fromAccount, err := server.getValidAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Balance < req.GetAmount() {
+		return nil, status.Errorf(codes.FailedPrecondition, "account [%d] balance %d is less than request amount %d", fromAccount.ID, fromAccount.Balance, req.GetAmount())
+	}
+
+	if _, err := server.getValidAccount(ctx, req.GetToAccountId(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	})
+	if err != nil {
+		return nil, apperr.From(err)
+	}
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}, nil
+}
+
+func (server *Server) getValidAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	// validated on the primary: a replica may not have the account yet
+	account, err := server.store.GetAccount(db.WithPrimaryReads(ctx), accountID)
+	if err != nil {
+		return db.Account{}, apperr.From(apperr.NotFoundAs(err, "account [%d]", accountID))
+	}
+
+	if account.Currency != currency {
+		return db.Account{}, status.Errorf(codes.InvalidArgument, "account [%d] currency %s does not match request currency %s", accountID, account.Currency, currency)
+	}
+
+	return account, nil
+}
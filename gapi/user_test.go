@@ -0,0 +1,138 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/pb"
+	"github.com/avfirsov/golang-backend-masterclass/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+)
+
+// eqCreateUserParamsMatcher matches CreateUserParams whose hash belongs to password.
+type eqCreateUserParamsMatcher struct {
+	arg      db.CreateUserParams
+	password string
+}
+
+func (e eqCreateUserParamsMatcher) Matches(x any) bool {
+	arg, ok := x.(db.CreateUserParams)
+	if !ok {
+		return false
+	}
+	if err := util.CheckPassword(e.password, arg.HashedPassword); err != nil {
+		return false
+	}
+	e.arg.HashedPassword = arg.HashedPassword
+	return e.arg == arg
+}
+
+func (e eqCreateUserParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func TestCreateUserRPC(t *testing.T) {
+	password := "secret123"
+	user := db.User{
+		Username: "alice",
+		FullName: util.RandomOwner(),
+		Email:    "alice@example.com",
+	}
+
+	testCases := []struct {
+		name       string
+		req        *pb.CreateUserRequest
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name: "OK",
+			req:  &pb.CreateUserRequest{Username: user.Username, FullName: user.FullName, Email: user.Email, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserParams{Username: user.Username, FullName: user.FullName, Email: user.Email}
+				store.EXPECT().CreateUser(gomock.Any(), eqCreateUserParamsMatcher{arg, password}).Times(1).Return(user, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "DuplicateUsername",
+			req:  &pb.CreateUserRequest{Username: user.Username, FullName: user.FullName, Email: user.Email, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, &pgconn.PgError{Code: "23505"})
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.CreateUserRequest{Username: user.Username, FullName: user.FullName, Email: "alice", Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "ShortPassword",
+			req:  &pb.CreateUserRequest{Username: user.Username, FullName: user.FullName, Email: user.Email, Password: "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			rsp, err := NewServer(store).CreateUser(context.Background(), tc.req)
+			if tc.code != codes.OK {
+				requireCode(t, err, tc.code)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+			require.Equal(t, user.Email, rsp.GetUser().GetEmail())
+		})
+	}
+}
+
+func TestGetUserRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq("bob")).Times(1).Return(db.User{}, pgx.ErrNoRows)
+
+	_, err := NewServer(store).GetUser(context.Background(), &pb.GetUserRequest{Username: "bob"})
+	requireCode(t, err, codes.NotFound)
+}
+
+func TestGetUserRPCPublicProfile(t *testing.T) {
+	user := db.User{
+		Username:          "alice",
+		FullName:          "Alice Smith",
+		Email:             "alice@example.com",
+		PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		CreatedAt:         pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+	res, err := NewServer(store).GetUser(context.Background(), &pb.GetUserRequest{Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, res.GetUser().GetUsername())
+	require.Empty(t, res.GetUser().GetEmail())
+	require.Empty(t, res.GetUser().GetFullName())
+	require.Nil(t, res.GetUser().GetPasswordChangedAt())
+}
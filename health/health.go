@@ -0,0 +1,116 @@
+// Package health runs the dependency checks behind the readiness endpoint.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses of a check and of a whole report.
+const (
+	StatusOK          = "ok"
+	StatusFailed      = "failed"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Check returns an error when the dependency it checks is unhealthy.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name     string
+	check    Check
+	critical bool
+}
+
+// Checker runs a set of checks concurrently, each bounded by a timeout.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. A failing critical check makes the service
+// unavailable; any other failing check only degrades it.
+func (checker *Checker) Add(name string, check Check, critical bool) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	checker.checks = append(checker.checks, namedCheck{name: name, check: check, critical: critical})
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string  `json:"status"`
+	Critical bool    `json:"critical"`
+	Duration float64 `json:"duration_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready reports whether the service should receive traffic.
+func (report Report) Ready() bool {
+	return report.Status != StatusUnavailable
+}
+
+// Run runs every check and summarizes the results.
+func (checker *Checker) Run(ctx context.Context) Report {
+	checker.mu.Lock()
+	checks := append([]namedCheck(nil), checker.checks...)
+	checker.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checker.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if check.critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (checker *Checker) run(ctx context.Context, check namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.check(ctx)
+	result := Result{
+		Status:   StatusOK,
+		Critical: check.critical,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
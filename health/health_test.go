@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("boom") }
+
+func TestChecker(t *testing.T) {
+	testCases := []struct {
+		name     string
+		critical Check
+		optional Check
+		status   string
+		ready    bool
+	}{
+		{name: "OK", critical: passing, optional: passing, status: StatusOK, ready: true},
+		{name: "Degraded", critical: passing, optional: failing, status: StatusDegraded, ready: true},
+		{name: "Unavailable", critical: failing, optional: passing, status: StatusUnavailable, ready: false},
+		{name: "BothFailing", critical: failing, optional: failing, status: StatusUnavailable, ready: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(time.Second)
+			checker.Add("critical", tc.critical, true)
+			checker.Add("optional", tc.optional, false)
+
+			report := checker.Run(context.Background())
+			require.Equal(t, tc.status, report.Status)
+			require.Equal(t, tc.ready, report.Ready())
+			require.Len(t, report.Checks, 2)
+			require.True(t, report.Checks["critical"].Critical)
+			require.False(t, report.Checks["optional"].Critical)
+		})
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, true)
+
+	report := checker.Run(context.Background())
+	require.Equal(t, StatusUnavailable, report.Status)
+	require.Equal(t, StatusFailed, report.Checks["slow"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Now()
+	heartbeat := NewHeartbeat(time.Minute)
+	heartbeat.now = func() time.Time { return now }
+
+	require.EqualError(t, heartbeat.Check(context.Background()), "not started")
+
+	heartbeat.Beat(nil)
+	require.NoError(t, heartbeat.Check(context.Background()))
+
+	heartbeat.Beat(errors.New("boom"))
+	require.EqualError(t, heartbeat.Check(context.Background()), "last run failed: boom")
+
+	heartbeat.Beat(nil)
+	now = now.Add(2 * time.Minute)
+	require.ErrorContains(t, heartbeat.Check(context.Background()), "no run since")
+}
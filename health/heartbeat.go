@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Heartbeat records the outcome of a background worker's iterations.
+type Heartbeat struct {
+	staleAfter time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	last time.Time
+	err  error
+}
+
+// NewHeartbeat returns a heartbeat that goes stale when no iteration
+// completes within staleAfter.
+func NewHeartbeat(staleAfter time.Duration) *Heartbeat {
+	return &Heartbeat{staleAfter: staleAfter, now: time.Now}
+}
+
+// Beat records a completed iteration and its error, if any.
+func (heartbeat *Heartbeat) Beat(err error) {
+	heartbeat.mu.Lock()
+	defer heartbeat.mu.Unlock()
+
+	heartbeat.last = heartbeat.now()
+	heartbeat.err = err
+}
+
+// Check fails when the worker has not run yet, its last iteration failed or
+// it has stalled.
+func (heartbeat *Heartbeat) Check(context.Context) error {
+	heartbeat.mu.Lock()
+	defer heartbeat.mu.Unlock()
+
+	switch {
+	case heartbeat.last.IsZero():
+		return errors.New("not started")
+	case heartbeat.err != nil:
+		return fmt.Errorf("last run failed: %w", heartbeat.err)
+	case heartbeat.now().Sub(heartbeat.last) > heartbeat.staleAfter:
+		return fmt.Errorf("no run since %s", heartbeat.last.Format(time.RFC3339))
+	}
+	return nil
+}
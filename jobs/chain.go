@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+)
+
+// VerifyAccountChain handles db.JobVerifyAccountChain. A broken chain fails
+// the job for good, leaving it in the failed jobs list for an operator.
+func VerifyAccountChain(store db.Store) Handler {
+	return func(ctx context.Context, job db.Job) error {
+		var payload db.VerifyAccountChainJobV1
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+
+		verification, err := store.VerifyAccountChain(ctx, payload.AccountID)
+		if err != nil {
+			return err
+		}
+		if !verification.Valid {
+			return Permanent(fmt.Errorf("account %d hash chain is broken at entry %d: %s",
+				payload.AccountID, verification.TamperedEntryID, verification.Reason))
+		}
+
+		logging.FromContext(ctx).Info("account hash chain verified", "account_id", payload.AccountID, "entries", verification.EntriesChecked)
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyAccountChain(t *testing.T) {
+	testCases := []struct {
+		name       string
+		payload    string
+		buildStubs func(store *mockdb.MockStore)
+		permanent  bool
+		ok         bool
+	}{
+		{
+			name:    "Valid",
+			payload: `{"account_id":3}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyAccountChain(gomock.Any(), int64(3)).Times(1).
+					Return(db.ChainVerification{AccountID: 3, EntriesChecked: 2, Valid: true}, nil)
+			},
+			ok: true,
+		},
+		{
+			name:    "Broken",
+			payload: `{"account_id":3}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyAccountChain(gomock.Any(), int64(3)).Times(1).
+					Return(db.ChainVerification{AccountID: 3, TamperedEntryID: 9, Reason: "hash does not match entry contents"}, nil)
+			},
+			permanent: true,
+		},
+		{
+			name:    "StoreError",
+			payload: `{"account_id":3}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyAccountChain(gomock.Any(), int64(3)).Times(1).Return(db.ChainVerification{}, pgx.ErrTxClosed)
+			},
+		},
+		{
+			name:    "InvalidPayload",
+			payload: `[]`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyAccountChain(gomock.Any(), gomock.Any()).Times(0)
+			},
+			permanent: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			err := VerifyAccountChain(store)(context.Background(), db.Job{
+				Kind:    db.JobVerifyAccountChain,
+				Payload: json.RawMessage(tc.payload),
+			})
+			if tc.ok {
+				require.NoError(t, err)
+				return
+			}
+
+			var permanent permanentError
+			require.Error(t, err)
+			require.Equal(t, tc.permanent, errors.As(err, &permanent))
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package jobs runs background work queued in the jobs table. Jobs are
+// enqueued in the transaction of the change that needs them and claimed with
+// SKIP LOCKED, so any number of instances can share the queue.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/avfirsov/golang-backend-masterclass/metrics"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultConcurrency       = 4
+	defaultInterval          = time.Second
+	defaultVisibilityTimeout = 5 * time.Minute
+	// leaves a worker time to record the outcome of an attempt that used up
+	// its whole visibility timeout before the job can be claimed again
+	recordGrace = 30 * time.Second
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// errLeaseExpired fails a job whose last attempt outlived its visibility
+// timeout, most likely because its worker died.
+var errLeaseExpired = errors.New("visibility timeout expired on the last attempt")
+
+// Handler performs one attempt of a job. Failed attempts are retried with
+// backoff until the job runs out of attempts, unless the error is Permanent.
+type Handler func(ctx context.Context, job db.Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: the job fails right away.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Pool claims jobs of the registered kinds and runs them on a bounded number
+// of concurrent workers.
+type Pool struct {
+	store             db.Store
+	handlers          map[string]Handler
+	concurrency       int
+	interval          time.Duration
+	visibilityTimeout time.Duration
+	now               func() time.Time
+	heartbeat         *health.Heartbeat
+}
+
+// NewPool creates a pool running up to concurrency jobs at a time. An attempt
+// is cancelled after visibilityTimeout, and a job whose worker stopped
+// without recording its outcome is claimed again shortly after.
+func NewPool(store db.Store, concurrency int, interval, visibilityTimeout time.Duration) *Pool {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = defaultVisibilityTimeout
+	}
+	return &Pool{
+		store:             store,
+		handlers:          map[string]Handler{},
+		concurrency:       concurrency,
+		interval:          interval,
+		visibilityTimeout: visibilityTimeout,
+		now:               time.Now,
+		// no job is claimed while every worker runs an attempt to its timeout
+		heartbeat: health.NewHeartbeat(interval + visibilityTimeout + recordGrace),
+	}
+}
+
+// Handle registers the handler of a job kind. Only jobs of registered kinds
+// are claimed, so instances may run different kinds.
+func (p *Pool) Handle(kind string, handler Handler) {
+	p.handlers[kind] = handler
+}
+
+// Run claims and runs jobs until ctx is cancelled, then waits for the
+// attempts in progress to finish.
+func (p *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	finished := make(chan struct{}, p.concurrency)
+	running := 0
+	defer func() {
+		for ; running > 0; running-- {
+			<-finished
+		}
+	}()
+
+	for {
+		if free := p.concurrency - running; free > 0 {
+			claimed, err := p.claim(ctx, free)
+			if ctx.Err() == nil {
+				p.heartbeat.Beat(err)
+			}
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("job pool failed to claim jobs", "error", err)
+			}
+
+			for _, job := range claimed {
+				running++
+				go func() {
+					p.process(ctx, job)
+					finished <- struct{}{}
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-finished:
+			running--
+		}
+	}
+}
+
+// Check reports whether the pool is running and its last claim succeeded.
+func (p *Pool) Check(ctx context.Context) error {
+	return p.heartbeat.Check(ctx)
+}
+
+func (p *Pool) claim(ctx context.Context, limit int) ([]db.Job, error) {
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	return p.store.ClaimJobs(ctx, db.ClaimJobsParams{
+		LockedUntil: pgtype.Timestamptz{Time: p.now().Add(p.visibilityTimeout + recordGrace), Valid: true},
+		Kinds:       kinds,
+		Limit:       int32(limit),
+	})
+}
+
+// process makes one attempt at a claimed job and records its outcome.
+func (p *Pool) process(ctx context.Context, job db.Job) {
+	logger := logging.FromContext(ctx).With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	// a started attempt is allowed to finish on shutdown; the visibility
+	// timeout bounds it
+	attemptCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.visibilityTimeout)
+	defer cancel()
+
+	start := p.now()
+	err := errLeaseExpired
+	if job.Attempts <= job.MaxAttempts {
+		err = p.attempt(logging.WithLogger(attemptCtx, logger), job)
+	}
+
+	status, recorded, recordErr := p.record(context.WithoutCancel(ctx), job, err)
+	metrics.JobAttempted(job.Kind, status, p.now().Sub(start))
+
+	switch {
+	case recordErr != nil:
+		logger.Error("failed to record job outcome", "status", status, "error", recordErr)
+	case !recorded:
+		logger.Warn("job was claimed again before its outcome was recorded", "status", status)
+	case err != nil:
+		logger.Warn("job attempt failed", "status", status, "error", err)
+	default:
+		logger.Debug("job succeeded", "duration", p.now().Sub(start))
+	}
+}
+
+func (p *Pool) attempt(ctx context.Context, job db.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	handler, ok := p.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", job.Kind))
+	}
+	return handler(ctx, job)
+}
+
+// record stores the outcome of an attempt, retrying failures with backoff
+// while the job has attempts left. It reports false when the job has been
+// claimed again in the meantime.
+func (p *Pool) record(ctx context.Context, job db.Job, err error) (string, bool, error) {
+	if err == nil {
+		n, err := p.store.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, Attempts: job.Attempts})
+		return db.JobStatusSucceeded, n > 0, err
+	}
+
+	lastError := pgtype.Text{String: err.Error(), Valid: true}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		n, err := p.store.FailJob(ctx, db.FailJobParams{ID: job.ID, Attempts: job.Attempts, LastError: lastError})
+		return db.JobStatusFailed, n > 0, err
+	}
+
+	n, err := p.store.RetryJob(ctx, db.RetryJobParams{
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+		RunAt:     pgtype.Timestamptz{Time: p.now().Add(Backoff(job.Attempts)), Valid: true},
+		LastError: lastError,
+	})
+	return db.JobStatusPending, n > 0, err
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts: 5s doubled per attempt, capped at 1h.
+func Backoff(attempts int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
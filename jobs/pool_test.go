@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testKind = "test"
+
+func TestProcess(t *testing.T) {
+	now := time.Now().UTC()
+
+	testCases := []struct {
+		name       string
+		attempts   int32
+		handler    Handler
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name:     "Succeeded",
+			attempts: 1,
+			handler:  func(context.Context, db.Job) error { return nil },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteJob(gomock.Any(), db.CompleteJobParams{ID: 1, Attempts: 1}).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "Retry",
+			attempts: 2,
+			handler:  func(context.Context, db.Job) error { return errors.New("boom") },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RetryJob(gomock.Any(), db.RetryJobParams{
+					ID:        1,
+					Attempts:  2,
+					RunAt:     pgtype.Timestamptz{Time: now.Add(Backoff(2)), Valid: true},
+					LastError: pgtype.Text{String: "boom", Valid: true},
+				}).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "OutOfAttempts",
+			attempts: 3,
+			handler:  func(context.Context, db.Job) error { return errors.New("boom") },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FailJob(gomock.Any(), db.FailJobParams{ID: 1, Attempts: 3, LastError: pgtype.Text{String: "boom", Valid: true}}).
+					Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "Permanent",
+			attempts: 1,
+			handler:  func(context.Context, db.Job) error { return Permanent(errors.New("bad payload")) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FailJob(gomock.Any(), db.FailJobParams{ID: 1, Attempts: 1, LastError: pgtype.Text{String: "bad payload", Valid: true}}).
+					Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "Panic",
+			attempts: 1,
+			handler:  func(context.Context, db.Job) error { panic("oops") },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RetryJob(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, arg db.RetryJobParams) (int64, error) {
+						require.Equal(t, "panic: oops", arg.LastError.String)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:     "LeaseExpired",
+			attempts: 4,
+			handler: func(context.Context, db.Job) error {
+				t.Fatal("an attempt past max_attempts must not run")
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FailJob(gomock.Any(), db.FailJobParams{ID: 1, Attempts: 4, LastError: pgtype.Text{String: errLeaseExpired.Error(), Valid: true}}).
+					Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "ClaimedAgain",
+			attempts: 1,
+			handler:  func(context.Context, db.Job) error { return nil },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteJob(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			pool := NewPool(store, 1, time.Second, time.Minute)
+			pool.now = func() time.Time { return now }
+			pool.Handle(testKind, tc.handler)
+
+			pool.process(context.Background(), db.Job{ID: 1, Kind: testKind, Attempts: tc.attempts, MaxAttempts: 3})
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	pool := NewPool(store, 2, time.Hour, time.Minute)
+	pool.Handle(testKind, func(ctx context.Context, job db.Job) error {
+		close(started)
+		<-release
+		// attempts in progress outlive the pool's context
+		return ctx.Err()
+	})
+
+	store.EXPECT().ClaimJobs(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, arg db.ClaimJobsParams) ([]db.Job, error) {
+			require.Equal(t, []string{testKind}, arg.Kinds)
+			require.EqualValues(t, 2, arg.Limit)
+			return []db.Job{{ID: 1, Kind: testKind, Attempts: 1, MaxAttempts: 3}}, nil
+		})
+	store.EXPECT().CompleteJob(gomock.Any(), db.CompleteJobParams{ID: 1, Attempts: 1}).Times(1).Return(int64(1), nil)
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	require.NoError(t, pool.Check(ctx))
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("Run returned while an attempt was in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 5*time.Second, Backoff(1))
+	require.Equal(t, 20*time.Second, Backoff(3))
+	require.Equal(t, time.Hour, Backoff(20))
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", FormatJSON)
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	logger.Warn("kept", "key", "value")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "kept", record["msg"])
+	require.Equal(t, "value", record["key"])
+
+	_, err = New(&buf, "loud", FormatJSON)
+	require.Error(t, err)
+
+	_, err = New(&buf, "info", "xml")
+	require.Error(t, err)
+}
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	require.NoError(t, err)
+
+	ctx := WithRequestID(WithLogger(context.Background(), logger), "req-1")
+	require.Equal(t, "req-1", RequestID(ctx))
+
+	FromContext(ctx).Info("hello")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "req-1", record["request_id"])
+
+	require.Empty(t, RequestID(context.Background()))
+	require.NotNil(t, FromContext(context.Background()))
+}
+
+func TestValidRequestID(t *testing.T) {
+	require.True(t, ValidRequestID("req-1"))
+	require.True(t, ValidRequestID("0af7651916cd43dd8448eb211c80319c.span_2"))
+	require.True(t, ValidRequestID(strings.Repeat("a", 64)))
+	require.True(t, ValidRequestID(NewRequestID()))
+
+	require.False(t, ValidRequestID(""))
+	require.False(t, ValidRequestID(strings.Repeat("a", 65)))
+	require.False(t, ValidRequestID("req 1"))
+	require.False(t, ValidRequestID("req-1\nlevel=ERROR"))
+	require.False(t, ValidRequestID("<script>"))
+	require.False(t, ValidRequestID("réq"))
+}
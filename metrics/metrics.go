@@ -0,0 +1,139 @@
+// Package metrics defines the service's Prometheus metrics. They are
+// registered with the default registry and served by Handler.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simplebank"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	transferTxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transfer_tx_duration_seconds",
+		Help:      "Duration of TransferTx calls by result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	txRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_total",
+		Help:      "Transactions retried, by transaction and error type.",
+	}, []string{"tx", "error_type"})
+
+	txFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_failures_total",
+		Help:      "Transactions that failed, by transaction and error type.",
+	}, []string{"tx", "error_type"})
+
+	reads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "reads_total",
+		Help:      "Read queries by the pool that served them and whether a replica failed over to the primary.",
+	}, []string{"pool", "fallback"})
+
+	replicaHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_healthy",
+		Help:      "Whether a read replica is receiving reads (1) or skipped (0).",
+	}, []string{"replica"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "attempt_duration_seconds",
+		Help:      "Duration of background job attempts by kind and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind", "outcome"})
+
+	transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Committed transfers by currency.",
+	}, []string{"currency"})
+
+	transferVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Amount moved by committed transfers, in minor units, by currency.",
+	}, []string{"currency"})
+)
+
+// Handler serves the default registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records one served request. route is the route pattern,
+// not the raw path, to keep the label set bounded.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveTransferTx records the duration of a TransferTx call. errorType is
+// empty when the transfer committed.
+func ObserveTransferTx(errorType string, duration time.Duration) {
+	result := "committed"
+	if errorType != "" {
+		result = "failed"
+	}
+	transferTxDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// TxRetried counts a transaction attempt that is about to be retried.
+func TxRetried(tx, errorType string) {
+	txRetries.WithLabelValues(tx, errorType).Inc()
+}
+
+// TxFailed counts a transaction that gave up with an error.
+func TxFailed(tx, errorType string) {
+	txFailures.WithLabelValues(tx, errorType).Inc()
+}
+
+// ReadServed counts a read query served by pool. fallback is set when a
+// replica failed and the primary served the read instead.
+func ReadServed(pool string, fallback bool) {
+	reads.WithLabelValues(pool, strconv.FormatBool(fallback)).Inc()
+}
+
+// SetReplicaHealthy records whether replica is receiving reads.
+func SetReplicaHealthy(replica string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	replicaHealthy.WithLabelValues(replica).Set(value)
+}
+
+// JobAttempted records one attempt of a background job. outcome is the
+// status the job was left in.
+func JobAttempted(kind, outcome string, duration time.Duration) {
+	jobDuration.WithLabelValues(kind, outcome).Observe(duration.Seconds())
+}
+
+// TransferCommitted counts a committed transfer and its amount.
+func TransferCommitted(currency string, amount int64) {
+	transfers.WithLabelValues(currency).Inc()
+	transferVolume.WithLabelValues(currency).Add(float64(amount))
+}
@@ -0,0 +1,112 @@
+// Package notify tells users about activity on their accounts by email and
+// in-app notifications, according to their preferences.
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+const defaultFrom = "Simple Bank <noreply@simplebank.local>"
+
+// Notification is a message to one user about one event.
+type Notification struct {
+	Username   string
+	Email      string
+	EventType  string
+	TransferID int64
+	Title      string
+	Body       string
+}
+
+// Notifier delivers notifications over one channel. A delivery may be
+// retried after a partial failure, so notifiers should tolerate repeats.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// InApp stores notifications for users to list and mark as read.
+type InApp struct {
+	store db.Querier
+}
+
+func NewInApp(store db.Querier) *InApp {
+	return &InApp{store: store}
+}
+
+// Notify stores the notification once; repeats of it are ignored.
+func (n *InApp) Notify(ctx context.Context, notification Notification) error {
+	_, err := n.store.CreateNotification(ctx, db.CreateNotificationParams{
+		Username:   notification.Username,
+		EventType:  notification.EventType,
+		TransferID: notification.TransferID,
+		Title:      notification.Title,
+		Body:       notification.Body,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// Message is an email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends email.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// LogSender stands in for a mail provider: it logs messages instead of
+// sending them. Bodies and full addresses are personal data and are not
+// logged.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, message Message) error {
+	logging.FromContext(ctx).Info("email sent", "to", maskAddress(message.To), "subject", message.Subject)
+	return nil
+}
+
+// maskAddress keeps the first character of the local part and the domain of
+// an email address, e.g. a***@example.com.
+func maskAddress(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return "***"
+	}
+	_, size := utf8.DecodeRuneInString(address)
+	return address[:size] + "***" + address[at:]
+}
+
+// Email mails notifications to the user's address.
+type Email struct {
+	sender Sender
+	from   string
+}
+
+func NewEmail(sender Sender, from string) *Email {
+	if from == "" {
+		from = defaultFrom
+	}
+	return &Email{sender: sender, from: from}
+}
+
+func (n *Email) Notify(ctx context.Context, notification Notification) error {
+	return n.sender.Send(ctx, Message{
+		From:    n.from,
+		To:      notification.Email,
+		Subject: notification.Title,
+		Body:    notification.Body,
+	})
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", logging.FormatJSON)
+	require.NoError(t, err)
+	ctx := logging.WithLogger(context.Background(), logger)
+
+	err = LogSender{}.Send(ctx, Message{
+		From:    defaultFrom,
+		To:      "alice@example.com",
+		Subject: "Transfer received",
+		Body:    "100 USD was received on account 2 from account 1.",
+	})
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "a***@example.com", record["to"])
+	require.Equal(t, "Transfer received", record["subject"])
+	require.NotContains(t, buf.String(), "alice@")
+	require.NotContains(t, buf.String(), "100 USD")
+}
+
+func TestMaskAddress(t *testing.T) {
+	require.Equal(t, "a***@example.com", maskAddress("alice@example.com"))
+	require.Equal(t, "é***@example.com", maskAddress("élise@example.com"))
+	require.Equal(t, "***", maskAddress("@example.com"))
+	require.Equal(t, "***", maskAddress("alice"))
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/jobs"
+	"github.com/jackc/pgx/v5"
+)
+
+// Transfers notifies account owners of the transfers they sent or received.
+type Transfers struct {
+	store db.Store
+	email Notifier
+	inApp Notifier
+}
+
+func NewTransfers(store db.Store, email, inApp Notifier) *Transfers {
+	return &Transfers{store: store, email: email, inApp: inApp}
+}
+
+// Handle is the jobs.Handler of db.JobNotifyTransfer. Transfers below the
+// owner's minimum amount for the event are skipped. The in-app notification
+// is stored before the email is sent, so a retry after a failed email does
+// not duplicate it.
+func (t *Transfers) Handle(ctx context.Context, job db.Job) error {
+	var payload db.NotifyTransferJobV1
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	// the job may run before the replicas have the transfer
+	ctx = db.WithPrimaryReads(ctx)
+
+	transfer, err := t.store.GetTransfer(ctx, payload.TransferID)
+	if err != nil {
+		return err
+	}
+
+	account, err := t.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		return err
+	}
+
+	preference, err := t.preference(ctx, account.Owner, payload.EventType)
+	if err != nil {
+		return err
+	}
+	if transfer.Amount < preference.MinAmount || !(preference.Email || preference.InApp) {
+		return nil
+	}
+
+	user, err := t.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	notification, err := transferNotification(user, payload.EventType, transfer, account.Currency)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	if preference.InApp {
+		if err := t.inApp.Notify(ctx, notification); err != nil {
+			return fmt.Errorf("in-app notification: %w", err)
+		}
+	}
+	if preference.Email {
+		if err := t.email.Notify(ctx, notification); err != nil {
+			return fmt.Errorf("email notification: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *Transfers) preference(ctx context.Context, username, eventType string) (db.NotificationPreference, error) {
+	preference, err := t.store.GetNotificationPreference(ctx, db.GetNotificationPreferenceParams{
+		Username:  username,
+		EventType: eventType,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.DefaultNotificationPreference(username, eventType), nil
+	}
+	return preference, err
+}
+
+func transferNotification(user db.User, eventType string, transfer db.Transfer, currency string) (Notification, error) {
+	notification := Notification{
+		Username:   user.Username,
+		Email:      user.Email,
+		EventType:  eventType,
+		TransferID: transfer.ID,
+	}
+
+	switch eventType {
+	case db.NotificationTransferSent:
+		notification.Title = "Transfer sent"
+		notification.Body = fmt.Sprintf("%d %s was sent from account %d to account %d.",
+			transfer.Amount, currency, transfer.FromAccountID, transfer.ToAccountID)
+	case db.NotificationTransferReceived:
+		notification.Title = "Transfer received"
+		notification.Body = fmt.Sprintf("%d %s was received on account %d from account %d.",
+			transfer.Amount, currency, transfer.ToAccountID, transfer.FromAccountID)
+	default:
+		return Notification{}, fmt.Errorf("unknown transfer event %q", eventType)
+	}
+	return notification, nil
+}
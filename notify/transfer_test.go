@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// recorder is a Notifier keeping what it was asked to deliver.
+type recorder struct {
+	notifications []Notification
+	err           error
+}
+
+func (r *recorder) Notify(_ context.Context, notification Notification) error {
+	r.notifications = append(r.notifications, notification)
+	return r.err
+}
+
+func TestTransfersHandle(t *testing.T) {
+	user := db.User{Username: "alice", Email: "alice@example.com"}
+	account := db.Account{ID: 2, Owner: user.Username, Currency: "USD"}
+	transfer := db.Transfer{ID: 9, FromAccountID: 1, ToAccountID: account.ID, Amount: 100}
+	payload, err := json.Marshal(db.NotifyTransferJobV1{
+		TransferID: transfer.ID,
+		AccountID:  account.ID,
+		EventType:  db.NotificationTransferReceived,
+	})
+	require.NoError(t, err)
+
+	preferenceArg := db.GetNotificationPreferenceParams{Username: user.Username, EventType: db.NotificationTransferReceived}
+
+	testCases := []struct {
+		name       string
+		preference func(store *mockdb.MockStore)
+		emailErr   error
+		email      int
+		inApp      int
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name: "Defaults",
+			preference: func(store *mockdb.MockStore) {
+				store.EXPECT().GetNotificationPreference(gomock.Any(), preferenceArg).Times(1).Return(db.NotificationPreference{}, pgx.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			email: 1,
+			inApp: 1,
+			check: func(t *testing.T, err error) { require.NoError(t, err) },
+		},
+		{
+			name: "InAppOnly",
+			preference: func(store *mockdb.MockStore) {
+				store.EXPECT().GetNotificationPreference(gomock.Any(), preferenceArg).Times(1).
+					Return(db.NotificationPreference{InApp: true, MinAmount: 100}, nil)
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			inApp: 1,
+			check: func(t *testing.T, err error) { require.NoError(t, err) },
+		},
+		{
+			name: "BelowMinAmount",
+			preference: func(store *mockdb.MockStore) {
+				store.EXPECT().GetNotificationPreference(gomock.Any(), preferenceArg).Times(1).
+					Return(db.NotificationPreference{Email: true, InApp: true, MinAmount: 101}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error) { require.NoError(t, err) },
+		},
+		{
+			name: "EmailFailed",
+			preference: func(store *mockdb.MockStore) {
+				store.EXPECT().GetNotificationPreference(gomock.Any(), preferenceArg).Times(1).Return(db.NotificationPreference{}, pgx.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			emailErr: errors.New("mailbox unavailable"),
+			email:    1,
+			inApp:    1,
+			check: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "email notification: mailbox unavailable")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+			store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+			tc.preference(store)
+
+			email, inApp := &recorder{err: tc.emailErr}, &recorder{}
+			err := NewTransfers(store, email, inApp).Handle(context.Background(), db.Job{Kind: db.JobNotifyTransfer, Payload: payload})
+			tc.check(t, err)
+
+			require.Len(t, email.notifications, tc.email)
+			require.Len(t, inApp.notifications, tc.inApp)
+			for _, notification := range append(email.notifications, inApp.notifications...) {
+				require.Equal(t, Notification{
+					Username:   user.Username,
+					Email:      user.Email,
+					EventType:  db.NotificationTransferReceived,
+					TransferID: transfer.ID,
+					Title:      "Transfer received",
+					Body:       "100 USD was received on account 2 from account 1.",
+				}, notification)
+			}
+		})
+	}
+}
+
+func TestInAppNotifyRepeated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.Notification{}, pgx.ErrNoRows)
+
+	require.NoError(t, NewInApp(store).Notify(context.Background(), Notification{Username: "alice", TransferID: 9}))
+}
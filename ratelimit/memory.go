@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// maxMemoryBuckets bounds the buckets a MemoryStore keeps, so that callers
+// rotating through keys cannot exhaust memory between sweeps.
+const maxMemoryBuckets = 100_000
+
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore keeps buckets in process memory. Full buckets are dropped
+// periodically so that the map does not grow with every caller ever seen,
+// and beyond maxBuckets the least recently used bucket is evicted.
+type MemoryStore struct {
+	now        func() time.Time
+	maxBuckets int
+
+	mu        sync.Mutex
+	buckets   map[string]*list.Element
+	recent    *list.List // of *bucket, most recently used first
+	lastSweep time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:        time.Now,
+		maxBuckets: maxMemoryBuckets,
+		buckets:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+func (store *MemoryStore) Take(_ context.Context, key string, limit Limit) (float64, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	if now.Sub(store.lastSweep) > sweepInterval {
+		store.sweep(now)
+	}
+
+	var b *bucket
+	if elem, ok := store.buckets[key]; ok {
+		b = elem.Value.(*bucket)
+		store.recent.MoveToFront(elem)
+	} else {
+		if len(store.buckets) >= store.maxBuckets {
+			store.evict(store.recent.Back())
+		}
+		b = &bucket{key: key, tokens: float64(limit.Burst), updated: now}
+		store.buckets[key] = store.recent.PushFront(b)
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// sweep drops buckets idle for longer than staleAfter; they would be full
+// again under any practical limit.
+func (store *MemoryStore) sweep(now time.Time) {
+	for elem := store.recent.Back(); elem != nil && now.Sub(elem.Value.(*bucket).updated) > staleAfter; elem = store.recent.Back() {
+		store.evict(elem)
+	}
+	store.lastSweep = now
+}
+
+func (store *MemoryStore) evict(elem *list.Element) {
+	store.recent.Remove(elem)
+	delete(store.buckets, elem.Value.(*bucket).key)
+}
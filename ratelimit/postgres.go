@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that all
+// instances share them.
+type PostgresStore struct {
+	store db.Store
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(store db.Store) *PostgresStore {
+	return &PostgresStore{store: store}
+}
+
+func (store *PostgresStore) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	store.sweepIfDue(ctx)
+
+	row, err := store.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return row.Tokens, row.Allowed, nil
+}
+
+// sweepIfDue deletes idle buckets in the background at most once per
+// sweepInterval per instance.
+func (store *PostgresStore) sweepIfDue(ctx context.Context) {
+	store.mu.Lock()
+	now := time.Now()
+	due := now.Sub(store.lastSweep) > sweepInterval
+	if due {
+		store.lastSweep = now
+	}
+	store.mu.Unlock()
+	if !due {
+		return
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		before := pgtype.Timestamptz{Time: now.Add(-staleAfter), Valid: true}
+		if _, err := store.store.DeleteStaleRateLimitBuckets(ctx, before); err != nil {
+			logging.FromContext(ctx).Warn("failed to delete stale rate limit buckets", "error", err)
+		}
+	}()
+}
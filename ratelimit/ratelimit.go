@@ -0,0 +1,136 @@
+// Package ratelimit implements token bucket rate limits per route class,
+// kept in memory or in Postgres for deployments with several instances.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+)
+
+const (
+	// sweepInterval is how often idle buckets are deleted.
+	sweepInterval = time.Minute
+	// staleAfter is how long a bucket may stay idle before it is deleted.
+	staleAfter = time.Hour
+)
+
+// Class groups routes that share a limit.
+type Class string
+
+const (
+	// ClassLogin covers requests that hash passwords, such as sign-up and
+	// HTTP requests carrying credentials.
+	ClassLogin     Class = "login"
+	ClassTransfers Class = "transfers"
+	ClassReads     Class = "reads"
+	ClassWrites    Class = "writes"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit of requests per minute allowing bursts of burst
+// requests. A burst below one defaults to the per-minute rate.
+func PerMinute(requests, burst int) Limit {
+	if burst < 1 {
+		burst = requests
+	}
+	return Limit{Rate: float64(requests) / 60, Burst: burst}
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (limit Limit) Unlimited() bool {
+	return limit.Rate <= 0 || limit.Burst <= 0
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size, zero when the class is unlimited.
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps token buckets.
+type Store interface {
+	// Take refills the bucket for key and takes a token from it if one is
+	// available, returning the tokens left.
+	Take(ctx context.Context, key string, limit Limit) (tokens float64, allowed bool, err error)
+}
+
+// Limiter applies per-class limits to callers.
+type Limiter struct {
+	store  Store
+	limits map[Class]Limit
+}
+
+func New(store Store, limits map[Class]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits}
+}
+
+// Allow takes a token for caller in class. Store failures let the request
+// through: an unavailable limiter must not take the API down with it.
+func (limiter *Limiter) Allow(ctx context.Context, class Class, caller string) Result {
+	limit := limiter.limits[class]
+	if limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	tokens, allowed, err := limiter.store.Take(ctx, string(class)+":"+caller, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("rate limiter unavailable", "class", class, "error", err)
+		return Result{Allowed: true}
+	}
+	return newResult(limit, tokens, allowed)
+}
+
+// Header returns the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers of the result, plus Retry-After when the request
+// was rejected. It is empty for unlimited classes.
+func (result Result) Header() http.Header {
+	header := make(http.Header)
+	if result.Limit == 0 {
+		return header
+	}
+
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+	}
+	return header
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
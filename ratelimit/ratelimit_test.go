@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	take := func(key string) bool {
+		_, allowed, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	require.True(t, take("a"))
+	require.True(t, take("a"))
+	require.False(t, take("a"))
+	require.True(t, take("b"), "buckets are per key")
+
+	now = now.Add(500 * time.Millisecond)
+	require.False(t, take("a"), "half a token is not enough")
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, take("a"))
+	require.False(t, take("a"))
+
+	now = now.Add(staleAfter + sweepInterval)
+	require.True(t, take("c"))
+	require.Len(t, store.buckets, 1, "idle buckets are swept")
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxBuckets = 2
+	limit := Limit{Rate: 1, Burst: 1}
+
+	take := func(key string) bool {
+		_, allowed, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	require.True(t, take("a"))
+	require.True(t, take("b"))
+	require.False(t, take("a"))
+
+	require.True(t, take("c"))
+	require.Len(t, store.buckets, 2)
+	require.NotContains(t, store.buckets, "b", "the least recently used bucket is evicted")
+	require.False(t, take("a"), "recently used buckets are kept")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (float64, bool, error) {
+	return 0, false, errors.New("connection refused")
+}
+
+func TestLimiter(t *testing.T) {
+	limits := map[Class]Limit{ClassTransfers: PerMinute(60, 1)}
+
+	limiter := New(NewMemoryStore(), limits)
+	result := limiter.Allow(context.Background(), ClassTransfers, "ip:10.0.0.1")
+	require.True(t, result.Allowed)
+	require.Equal(t, "1", result.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", result.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "1", result.Header().Get("RateLimit-Reset"))
+	require.Empty(t, result.Header().Get("Retry-After"))
+
+	result = limiter.Allow(context.Background(), ClassTransfers, "ip:10.0.0.1")
+	require.False(t, result.Allowed)
+	require.Equal(t, "1", result.Header().Get("Retry-After"))
+
+	result = limiter.Allow(context.Background(), ClassReads, "ip:10.0.0.1")
+	require.True(t, result.Allowed, "classes without a limit are unlimited")
+	require.Empty(t, result.Header())
+
+	result = New(failingStore{}, limits).Allow(context.Background(), ClassTransfers, "ip:10.0.0.1")
+	require.True(t, result.Allowed, "store failures let requests through")
+}
+
+func TestPerMinute(t *testing.T) {
+	require.Equal(t, Limit{Rate: 2, Burst: 10}, PerMinute(120, 10))
+	require.Equal(t, Limit{Rate: 0.5, Burst: 30}, PerMinute(30, 0))
+	require.True(t, PerMinute(0, 0).Unlimited())
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields are redacted from JSON documents at any depth.
+var sensitiveFields = []string{"password", "secret", "token", "authorization"}
+
+// RedactJSON returns body with the values of sensitive keys replaced, or nil
+// if body is not valid JSON.
+func RedactJSON(body []byte) []byte {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil
+	}
+
+	redactedBody, err := json.Marshal(redact(value))
+	if err != nil {
+		return nil
+	}
+	return redactedBody
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
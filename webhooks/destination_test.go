@@ -0,0 +1,40 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+		ok   bool
+	}{
+		{name: "Public", url: "https://93.184.215.14/hooks", ok: true},
+		{name: "PublicIPv6", url: "https://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]/hooks", ok: true},
+		{name: "Localhost", url: "http://localhost:8080/hooks"},
+		{name: "Loopback", url: "http://127.0.0.1/hooks"},
+		{name: "LoopbackIPv6", url: "http://[::1]/hooks"},
+		{name: "MappedLoopback", url: "http://[::ffff:127.0.0.1]/hooks"},
+		{name: "Metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "Private", url: "https://192.168.1.10/hooks"},
+		{name: "SharedAddressSpace", url: "https://100.64.0.1/hooks"},
+		{name: "Unspecified", url: "http://0.0.0.0/hooks"},
+		{name: "UniqueLocal", url: "http://[fd00::1]/hooks"},
+		{name: "Scheme", url: "file:///etc/passwd"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), tc.url)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
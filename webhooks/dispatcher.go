@@ -0,0 +1,232 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/health"
+	"github.com/avfirsov/golang-backend-masterclass/logging"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	defaultBatchSize   = 20
+	defaultMaxAttempts = 8
+	defaultTimeout     = 10 * time.Second
+	defaultInterval    = time.Second
+	baseBackoff        = 10 * time.Second
+	maxBackoff         = 6 * time.Hour
+)
+
+// Dispatcher sends pending deliveries to subscriber endpoints, retrying
+// failures with exponential backoff until they are dead-lettered.
+type Dispatcher struct {
+	store       db.Store
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int32
+	batchSize   int32
+	now         func() time.Time
+	heartbeat   *health.Heartbeat
+}
+
+func NewDispatcher(store db.Store, interval, timeout time.Duration, maxAttempts int32) *Dispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Dispatcher{
+		store:       store,
+		client:      newClient(timeout),
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		batchSize:   defaultBatchSize,
+		now:         time.Now,
+		// a batch may wait for the client timeout on every delivery
+		heartbeat: health.NewHeartbeat(interval + defaultBatchSize*timeout),
+	}
+}
+
+// Run dispatches due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		dispatched, err := d.DispatchBatch(ctx)
+		if ctx.Err() == nil {
+			d.heartbeat.Beat(err)
+		}
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("webhook dispatcher failed", "error", err)
+		}
+
+		if err == nil && dispatched == int(d.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports whether the dispatcher is running and its last batch
+// succeeded.
+func (d *Dispatcher) Check(ctx context.Context) error {
+	return d.heartbeat.Check(ctx)
+}
+
+// DispatchBatch claims due deliveries and attempts each of them once. Claimed
+// deliveries are leased so other instances skip them: the batch for long
+// enough to wait for the client timeout on every delivery, and each delivery
+// again right before it is sent. A delivery whose lease was lost meanwhile is
+// left to the instance holding it. A delivery that cannot be attempted or
+// recorded does not hold up the rest of the batch; the errors are returned
+// joined once the batch is done.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamptz{Time: d.now().Add(time.Duration(d.batchSize+1) * d.client.Timeout), Valid: true},
+		Limit:      d.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, delivery := range deliveries {
+		_, err := d.attempt(ctx, delivery)
+		if errors.Is(err, errLeaseLost) {
+			logging.FromContext(ctx).Warn("webhook delivery lease lost", "delivery_id", delivery.ID)
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("webhook delivery failed", "delivery_id", delivery.ID, "error", err)
+			errs = append(errs, fmt.Errorf("delivery %d: %w", delivery.ID, err))
+		}
+	}
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// errLeaseLost reports a claimed delivery that was claimed again by another
+// instance after its lease expired.
+var errLeaseLost = errors.New("delivery lease lost")
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery db.WebhookDelivery) (db.WebhookDelivery, error) {
+	delivery, err := d.store.ExtendWebhookDeliveryLease(ctx, db.ExtendWebhookDeliveryLeaseParams{
+		LeaseUntil:  pgtype.Timestamptz{Time: d.now().Add(2 * d.client.Timeout), Valid: true},
+		ID:          delivery.ID,
+		LeasedUntil: delivery.NextAttemptAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return delivery, errLeaseLost
+	}
+	if err != nil {
+		return delivery, err
+	}
+
+	subscription, err := d.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return delivery, err
+	}
+
+	statusCode, sendErr := d.send(ctx, subscription, delivery)
+
+	arg := db.RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         StatusDelivered,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: pgtype.Int4{Int32: int32(statusCode), Valid: statusCode != 0},
+	}
+
+	if sendErr == nil {
+		arg.DeliveredAt = pgtype.Timestamptz{Time: d.now(), Valid: true}
+	} else {
+		attempts := delivery.Attempts + 1
+		arg.LastError = pgtype.Text{String: sendErr.Error(), Valid: true}
+		arg.Status = StatusPending
+		arg.NextAttemptAt = pgtype.Timestamptz{Time: d.now().Add(Backoff(attempts)), Valid: true}
+		if attempts >= d.maxAttempts {
+			arg.Status = StatusDead
+		}
+	}
+
+	return d.store.RecordWebhookDeliveryAttempt(ctx, arg)
+}
+
+func (d *Dispatcher) send(ctx context.Context, subscription db.WebhookSubscription, delivery db.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(struct {
+		ID      int64           `json:"id"`
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}{
+		ID:      delivery.EventID,
+		Type:    delivery.EventType,
+		Payload: delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts: 10s doubled per attempt, capped at 6h.
+func Backoff(attempts int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,239 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/avfirsov/golang-backend-masterclass/db/mock"
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDispatchBatch(t *testing.T) {
+	now := time.Now().UTC()
+	payload := []byte(`{"transfer_id":3,"amount":10}`)
+
+	testCases := []struct {
+		name        string
+		statusCode  int
+		attempts    int32
+		checkRecord func(arg db.RecordWebhookDeliveryAttemptParams)
+	}{
+		{
+			name:       "Delivered",
+			statusCode: http.StatusNoContent,
+			checkRecord: func(arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, StatusDelivered, arg.Status)
+				require.Equal(t, int32(http.StatusNoContent), arg.ResponseStatus.Int32)
+				require.True(t, arg.DeliveredAt.Valid)
+				require.False(t, arg.LastError.Valid)
+			},
+		},
+		{
+			name:       "Retry",
+			statusCode: http.StatusInternalServerError,
+			attempts:   2,
+			checkRecord: func(arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, StatusPending, arg.Status)
+				require.Equal(t, int32(http.StatusInternalServerError), arg.ResponseStatus.Int32)
+				require.Equal(t, now.Add(Backoff(3)), arg.NextAttemptAt.Time)
+				require.True(t, arg.LastError.Valid)
+				require.False(t, arg.DeliveredAt.Valid)
+			},
+		},
+		{
+			name:       "DeadLetter",
+			statusCode: http.StatusBadGateway,
+			attempts:   4,
+			checkRecord: func(arg db.RecordWebhookDeliveryAttemptParams) {
+				require.Equal(t, StatusDead, arg.Status)
+				require.True(t, arg.LastError.Valid)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subscription := db.WebhookSubscription{ID: 5, EventType: db.EventTransferCreated, Secret: "s3cret"}
+
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				require.NoError(t, err)
+				require.True(t, Verify(subscription.Secret, timestamp, body, r.Header.Get(HeaderSignature)))
+				require.Equal(t, db.EventTransferCreated, r.Header.Get(HeaderEvent))
+
+				var got struct {
+					ID      int64           `json:"id"`
+					Payload json.RawMessage `json:"payload"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Equal(t, int64(42), got.ID)
+				require.JSONEq(t, string(payload), string(got.Payload))
+
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer endpoint.Close()
+			subscription.Url = endpoint.URL
+
+			delivery := db.WebhookDelivery{
+				ID:             9,
+				SubscriptionID: subscription.ID,
+				EventID:        42,
+				EventType:      db.EventTransferCreated,
+				Payload:        payload,
+				Status:         StatusPending,
+				Attempts:       tc.attempts,
+				NextAttemptAt:  pgtype.Timestamptz{Time: now, Valid: true},
+			}
+
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.WebhookDelivery{delivery}, nil)
+			expectLeaseRenewals(store, delivery)
+			store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+			store.EXPECT().RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					tc.checkRecord(arg)
+					return delivery, nil
+				})
+
+			dispatcher := NewDispatcher(store, time.Second, time.Second, 5)
+			dispatcher.now = func() time.Time { return now }
+			// the test endpoint listens on loopback
+			dispatcher.client.Transport = endpoint.Client().Transport
+
+			dispatched, err := dispatcher.DispatchBatch(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, dispatched)
+		})
+	}
+}
+
+func TestDispatchBatchNonPublicDestination(t *testing.T) {
+	requested := false
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer endpoint.Close()
+
+	subscription := db.WebhookSubscription{ID: 5, Url: endpoint.URL, Secret: "s3cret"}
+	delivery := db.WebhookDelivery{ID: 9, SubscriptionID: subscription.ID, EventID: 42, Payload: []byte(`{}`), Status: StatusPending}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.WebhookDelivery{delivery}, nil)
+	expectLeaseRenewals(store, delivery)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+	store.EXPECT().RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, StatusPending, arg.Status)
+			require.Contains(t, arg.LastError.String, ErrNonPublicDestination.Error())
+			return delivery, nil
+		})
+
+	_, err := NewDispatcher(store, time.Second, time.Second, 5).DispatchBatch(context.Background())
+	require.NoError(t, err)
+	require.False(t, requested)
+}
+
+func TestDispatchBatchContinuesAfterError(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	subscription := db.WebhookSubscription{ID: 5, Url: endpoint.URL, Secret: "s3cret"}
+	deliveries := []db.WebhookDelivery{
+		{ID: 1, SubscriptionID: 404, EventID: 41, Payload: []byte(`{}`), Status: StatusPending},
+		{ID: 2, SubscriptionID: subscription.ID, EventID: 42, Payload: []byte(`{}`), Status: StatusPending},
+	}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return(deliveries, nil)
+	expectLeaseRenewals(store, deliveries...)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(int64(404))).Times(1).Return(db.WebhookSubscription{}, pgx.ErrNoRows)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+	store.EXPECT().RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, deliveries[1].ID, arg.ID)
+			require.Equal(t, StatusDelivered, arg.Status)
+			return deliveries[1], nil
+		})
+
+	dispatcher := NewDispatcher(store, time.Second, time.Second, 5)
+	dispatcher.client.Transport = endpoint.Client().Transport
+
+	dispatched, err := dispatcher.DispatchBatch(context.Background())
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	require.ErrorContains(t, err, "delivery 1")
+	require.Equal(t, 2, dispatched)
+}
+
+func TestDispatchBatchLeaseLost(t *testing.T) {
+	now := time.Now()
+	deliveries := []db.WebhookDelivery{
+		{ID: 1, SubscriptionID: 5, NextAttemptAt: pgtype.Timestamptz{Time: now, Valid: true}, Status: StatusPending},
+		{ID: 2, SubscriptionID: 5, NextAttemptAt: pgtype.Timestamptz{Time: now, Valid: true}, Status: StatusPending},
+	}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ClaimDueWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+			// long enough to wait for the client timeout on every delivery
+			require.Equal(t, now.Add(time.Duration(arg.Limit+1)*time.Second), arg.LeaseUntil.Time)
+			return deliveries, nil
+		})
+	// another instance claimed both after the batch lease expired
+	store.EXPECT().ExtendWebhookDeliveryLease(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(_ context.Context, arg db.ExtendWebhookDeliveryLeaseParams) (db.WebhookDelivery, error) {
+			require.Equal(t, now.Add(2*time.Second), arg.LeaseUntil.Time)
+			require.Equal(t, deliveries[0].NextAttemptAt, arg.LeasedUntil)
+			return db.WebhookDelivery{}, pgx.ErrNoRows
+		})
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(0)
+
+	dispatcher := NewDispatcher(store, time.Second, time.Second, 5)
+	dispatcher.now = func() time.Time { return now }
+
+	dispatched, err := dispatcher.DispatchBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, dispatched)
+}
+
+// expectLeaseRenewals lets the dispatcher renew the lease of each delivery
+// once.
+func expectLeaseRenewals(store *mockdb.MockStore, deliveries ...db.WebhookDelivery) {
+	store.EXPECT().ExtendWebhookDeliveryLease(gomock.Any(), gomock.Any()).Times(len(deliveries)).
+		DoAndReturn(func(_ context.Context, arg db.ExtendWebhookDeliveryLeaseParams) (db.WebhookDelivery, error) {
+			for _, delivery := range deliveries {
+				if delivery.ID == arg.ID {
+					delivery.NextAttemptAt = arg.LeaseUntil
+					return delivery, nil
+				}
+			}
+			return db.WebhookDelivery{}, pgx.ErrNoRows
+		})
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 10*time.Second, Backoff(1))
+	require.Equal(t, 20*time.Second, Backoff(2))
+	require.Equal(t, 80*time.Second, Backoff(4))
+	require.Equal(t, maxBackoff, Backoff(30))
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+
+	db "github.com/avfirsov/golang-backend-masterclass/db/sqlc"
+	"github.com/avfirsov/golang-backend-masterclass/events"
+)
+
+// Publisher fans relayed events out into pending deliveries for every
+// subscription of the event type whose owner holds one of the affected accounts.
+type Publisher struct {
+	store db.Store
+}
+
+var _ events.Publisher = (*Publisher)(nil)
+
+func NewPublisher(store db.Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event events.Event) error {
+	accountIDs, err := affectedAccounts(event)
+	if err != nil {
+		return err
+	}
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	// deliveries are unique per subscription and event, so an event relayed
+	// twice is only delivered once
+	_, err = p.store.EnqueueWebhookDeliveries(ctx, db.EnqueueWebhookDeliveriesParams{
+		EventID:    event.ID,
+		Payload:    event.Payload,
+		EventType:  event.Type,
+		AccountIds: accountIDs,
+	})
+	return err
+}
+
+func affectedAccounts(event events.Event) ([]int64, error) {
+	var payload struct {
+		AccountID     int64 `json:"account_id"`
+		FromAccountID int64 `json:"from_account_id"`
+		ToAccountID   int64 `json:"to_account_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, id := range []int64{payload.AccountID, payload.FromAccountID, payload.ToAccountID} {
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}